@@ -9,8 +9,16 @@ import (
 	"github.com/brunotm/norm/statement"
 )
 
+// ErrCursorOpen is returned by transaction operations attempted while a Cursor is open within
+// the same transaction, as the result set is still being read from the transaction connection.
+var ErrCursorOpen = fmt.Errorf("database: operation not allowed while a cursor is open")
+
 // Cursor is a cursor to a database result set.
+// An open cursor holds the transaction, so that other operations within the same transaction
+// fail with ErrCursorOpen until the cursor is closed or fully iterated.
 type Cursor struct {
+	tx        *Tx
+	query     string
 	rows      *sql.Rows
 	vType     reflect.Type
 	columns   []string
@@ -44,7 +52,7 @@ func (c *Cursor) Scan(dst interface{}) (err error) {
 	}
 
 	ptr := c.extractor(c.columns, v)
-	err = c.rows.Scan(ptr...)
+	err = scan.ScanRow(c.rows, c.columns, c.vType, ptr)
 	if err != nil {
 		return err
 	}
@@ -58,7 +66,10 @@ func (c *Cursor) Scan(dst interface{}) (err error) {
 //
 // Every call to Scan, even the first one, must be preceded by a call to Next.
 func (c *Cursor) Next() (ok bool) {
-	return c.rows.Next()
+	if ok = c.rows.Next(); !ok {
+		c.release()
+	}
+	return ok
 }
 
 // Err returns the error, if any, that was encountered during iteration.
@@ -72,7 +83,54 @@ func (c *Cursor) Err() (err error) {
 // the Cursor is closed automatically and it will suffice to check the result of Err.
 // Close is idempotent and does not affect the result of Err.
 func (c *Cursor) Close() (err error) {
-	return c.rows.Close()
+	err = c.rows.Close()
+	c.release()
+	return err
+}
+
+// Reset closes the cursor and executes its query again within the transaction, so the result set
+// can be iterated again from the start, e.g. for multi pass algorithms, without building the statement again.
+// The destination type can change after a reset.
+func (c *Cursor) Reset() (err error) {
+	if err = c.Close(); err != nil {
+		return err
+	}
+
+	c.tx.mu.Lock()
+	defer c.tx.mu.Unlock()
+
+	if err = c.tx.checkCursor(); err != nil {
+		return err
+	}
+
+	r, err := c.tx.tx.QueryContext(c.tx.ctx, c.query)
+	if err != nil {
+		return err
+	}
+
+	columns, err := r.Columns()
+	if err != nil {
+		_ = r.Close()
+		return fmt.Errorf("statement: %w", err)
+	}
+
+	c.rows = r
+	c.columns = columns
+	c.vType = nil
+	c.extractor = nil
+	c.tx.cursor = c
+
+	return nil
+}
+
+// release releases the transaction held by the cursor.
+func (c *Cursor) release() {
+	c.tx.mu.Lock()
+	defer c.tx.mu.Unlock()
+
+	if c.tx.cursor == c {
+		c.tx.cursor = nil
+	}
 }
 
 // Cursor executes a query that returns a database cursor like sql.Rows.
@@ -80,12 +138,17 @@ func (c *Cursor) Close() (err error) {
 // is a concern.
 //
 // The caller must call Cursor.Close() on the returned cursor in order to release
-// the sql.Rows resources.
+// the sql.Rows resources. Other operations within the transaction, including opening
+// another cursor, return ErrCursorOpen until the cursor is closed or fully iterated.
 func (t *Tx) Cursor(stmt statement.Statement) (i *Cursor, err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	query, err := stmt.String()
+	if err = t.checkCursor(); err != nil {
+		return nil, err
+	}
+
+	query, err := t.build(stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +159,43 @@ func (t *Tx) Cursor(stmt statement.Statement) (i *Cursor, err error) {
 	}
 
 	cursor := &Cursor{}
+	cursor.tx = t
+	cursor.query = query
 	cursor.rows = r
 	if cursor.columns, err = r.Columns(); err != nil {
+		_ = r.Close()
 		return nil, fmt.Errorf("statement: %w", err)
 	}
+	t.cursor = cursor
 
 	return cursor, nil
 }
+
+// Iterate executes a query and calls fn for each row of the result set.
+// The scan function passed to fn copies the current row columns into dst as in Cursor.Scan.
+//
+// Iteration stops at the first error returned by fn, which is then returned by Iterate.
+// The underlying cursor is always closed before Iterate returns.
+//
+// The underlying cursor holds the transaction while iterating, so fn must not perform other operations
+// within the transaction, which return ErrCursorOpen. Rows needed for further operations should be
+// collected by fn and processed after Iterate returns.
+func (t *Tx) Iterate(stmt statement.Statement, fn func(scan func(dst interface{}) error) error) (err error) {
+	cursor, err := t.Cursor(stmt)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		if err = fn(cursor.Scan); err != nil {
+			return err
+		}
+	}
+
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	return cursor.Close()
+}
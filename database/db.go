@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"reflect"
 	"strconv"
 	"time"
+
+	"github.com/brunotm/norm/statement"
 )
 
 // Logger type for database operations
@@ -20,25 +23,44 @@ func DefaultLogger(message, tid string, err error, d time.Duration, query string
 
 func nopLogger(message, id string, err error, d time.Duration, query string) {}
 
+// QueryRewriter is invoked with every query before it is executed within a transaction,
+// returning the query to be executed, which can be transformed, or an error to reject it.
+type QueryRewriter func(ctx context.Context, query string) (q string, err error)
+
+// LogRedactor produces the loggable representation of a query, allowing sensitive values to be masked before logging.
+// It receives the built query with inlined values and nil args, or the prepared statement query and its args
+// for prepared statement executions.
+type LogRedactor func(query string, args []interface{}) string
+
 // DB is safe sql.DB wrapper which enforces transactional access to the database,
 // transaction query caching and operation logging and plays nicely with `noorm/statement`.
 type DB struct {
-	db       *sql.DB
-	log      Logger
-	readOpt  *sql.TxOptions
-	writeOpt *sql.TxOptions
+	db               *sql.DB
+	rawLog           Logger // the configured logger
+	filteredLog      Logger // rawLog filtered by the slow query threshold, used for logging
+	readOpt          *sql.TxOptions
+	writeOpt         *sql.TxOptions
+	statementTimeout time.Duration
+	rewrite          QueryRewriter
+	redact           LogRedactor
+	dialect          *statement.Dialect
 }
 
 // New creates a new database from an existing *sql.DB
 // with the given sql.IsolationLevel and logger.
 func New(db *sql.DB, level sql.IsolationLevel, logger Logger) (d *DB, err error) {
+	if db == nil {
+		return nil, fmt.Errorf("database: nil *sql.DB")
+	}
+
 	d = &DB{}
 	d.db = db
-	d.log = nopLogger
+	d.filteredLog = nopLogger
 
 	if logger != nil {
-		d.log = logger
+		d.filteredLog = logger
 	}
+	d.rawLog = d.filteredLog
 
 	d.readOpt = &sql.TxOptions{Isolation: level, ReadOnly: true}
 	d.writeOpt = &sql.TxOptions{Isolation: level, ReadOnly: false}
@@ -56,20 +78,39 @@ func (d *DB) Tx(ctx context.Context, tid string, opts *sql.TxOptions) (tx *Tx, e
 
 	start := time.Now()
 	t, err := d.db.BeginTx(ctx, opts)
-	d.log("db.begin", tid, err, time.Since(start), "")
+	d.filteredLog("db.begin", tid, err, time.Since(start), "")
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &Tx{
-		tid:   tid,
-		log:   d.log,
-		tx:    t,
-		ctx:   ctx,
-		cache: map[uint64]reflect.Value{},
-	}, nil
+	tx = &Tx{
+		tid:      tid,
+		queryLog: d.filteredLog,
+		argsLog:  d.filteredLog,
+		tx:       t,
+		ctx:      ctx,
+		cache:    map[uint64]reflect.Value{},
+		rewrite:  d.rewrite,
+		redact:   d.redact,
+		dialect:  d.dialect,
+	}
+
+	if d.redact != nil {
+		logger, redact := d.filteredLog, d.redact
+		tx.queryLog = func(message, tid string, err error, elapsed time.Duration, query string) {
+			logger(message, tid, err, elapsed, redact(query, nil))
+		}
+	}
 
+	if d.statementTimeout > 0 {
+		if err = tx.SetStatementTimeout(d.statementTimeout); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
 }
 
 // Read creates a read-only transaction with the default DB isolation level.
@@ -86,12 +127,164 @@ func (d *DB) Update(ctx context.Context, tid string) (tx *Tx, err error) {
 	return d.Tx(ctx, tid, d.writeOpt)
 }
 
+// SetMaxOpenConns sets the maximum number of open connections to the database.
+// See sql.DB.SetMaxOpenConns for details.
+func (d *DB) SetMaxOpenConns(n int) {
+	d.db.SetMaxOpenConns(n)
+}
+
+// SetMaxIdleConns sets the maximum number of connections in the idle connection pool.
+// See sql.DB.SetMaxIdleConns for details.
+func (d *DB) SetMaxIdleConns(n int) {
+	d.db.SetMaxIdleConns(n)
+}
+
+// SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
+// See sql.DB.SetConnMaxLifetime for details.
+func (d *DB) SetConnMaxLifetime(t time.Duration) {
+	d.db.SetConnMaxLifetime(t)
+}
+
+// SetConnMaxIdleTime sets the maximum amount of time a connection may be idle.
+// See sql.DB.SetConnMaxIdleTime for details.
+func (d *DB) SetConnMaxIdleTime(t time.Duration) {
+	d.db.SetConnMaxIdleTime(t)
+}
+
+// SetSlowQueryThreshold makes the DB only log operations that take longer than the given threshold,
+// while operations that fail are always logged. A zero duration logs all operations (default).
+// It must be called before creating transactions, as it affects transactions created afterwards.
+func (d *DB) SetSlowQueryThreshold(t time.Duration) {
+	if t <= 0 {
+		d.filteredLog = d.rawLog
+		return
+	}
+
+	logger := d.rawLog
+	d.filteredLog = func(message, tid string, err error, elapsed time.Duration, query string) {
+		if err != nil || elapsed >= t {
+			logger(message, tid, err, elapsed, query)
+		}
+	}
+}
+
+// SetQueryRewriter sets a QueryRewriter invoked with every query built or prepared within transactions
+// created afterwards, allowing queries to be inspected, transformed or rejected before execution,
+// e.g. to add a tenant filter or comment. Rewrites are logged. A nil rewriter disables it (default).
+func (d *DB) SetQueryRewriter(rewrite QueryRewriter) {
+	d.rewrite = rewrite
+}
+
+// SetLogRedactor sets a LogRedactor for transactions created afterwards, so that the logged queries
+// are the representation produced by the redactor, e.g. masking passwords or tokens.
+// A nil redactor logs queries as they are executed (default).
+func (d *DB) SetLogRedactor(redact LogRedactor) {
+	d.redact = redact
+}
+
+// SetDialect sets the statement.Dialect of the database for transactions created afterwards,
+// used to choose the placeholder format of the statements prepared by Tx.InsertMany.
+// A nil dialect uses `?` placeholders (default).
+func (d *DB) SetDialect(dialect *statement.Dialect) {
+	d.dialect = dialect
+}
+
+// SetStatementTimeout sets a server side statement timeout for all transactions created from the DB,
+// issued with `SET LOCAL statement_timeout` right after the transaction begins.
+// This is PostgreSQL specific and disabled by default, a zero duration disables it.
+// It can be overridden for a single transaction with Tx.SetStatementTimeout.
+func (d *DB) SetStatementTimeout(t time.Duration) {
+	d.statementTimeout = t
+}
+
+// Stats returns the underlying database connection pool statistics.
+func (d *DB) Stats() (s sql.DBStats) {
+	return d.db.Stats()
+}
+
 // PingContext verifies a connection to the database is still alive,
 // establishing a connection if necessary.
 func (d *DB) Ping(ctx context.Context) (err error) {
 	return d.db.PingContext(ctx)
 }
 
+// maxReadyBackoff is the upper bound for the delay between WaitReady attempts.
+const maxReadyBackoff = 30 * time.Second
+
+// WaitReady pings the database until it succeeds, the context is done or maxAttempts pings have failed,
+// waiting between attempts starting with the backoff duration and doubling it after each attempt, up to 30s.
+// A maxAttempts lower than 1 retries until the context is done. The backoff must be greater than zero.
+// It is meant to be used when starting services which depend on the database.
+func (d *DB) WaitReady(ctx context.Context, maxAttempts int, backoff time.Duration) (err error) {
+	if backoff <= 0 {
+		return fmt.Errorf("database: invalid wait ready backoff: %s", backoff)
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = d.db.PingContext(ctx)
+		d.filteredLog("db.wait.ready", "", err, time.Since(start), "")
+
+		if err == nil {
+			return nil
+		}
+
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			return fmt.Errorf("database: not ready after %d attempts: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > maxReadyBackoff {
+			backoff = maxReadyBackoff
+		}
+	}
+}
+
+// Health represents the result of a database health check.
+type Health struct {
+	Ping  time.Duration // ping round trip duration
+	Query time.Duration // query round trip duration, zero if the query check was not performed
+}
+
+// HealthCheck verifies that the database is reachable, and if query is true that queries can be
+// performed by running a `SELECT 1` within a read-only transaction.
+// It is meant to be used in readiness probes.
+func (d *DB) HealthCheck(ctx context.Context, query bool) (h Health, err error) {
+	start := time.Now()
+	err = d.db.PingContext(ctx)
+	h.Ping = time.Since(start)
+	d.filteredLog("db.health.ping", "", err, h.Ping, "")
+
+	if err != nil || !query {
+		return h, err
+	}
+
+	start = time.Now()
+	tx, err := d.Read(ctx, "")
+	if err != nil {
+		return h, err
+	}
+
+	var one int
+	err = tx.QuerySQL(&one, "SELECT 1")
+	_ = tx.Rollback()
+
+	if err == nil && one != 1 {
+		err = fmt.Errorf("database: unexpected health check query result: %d", one)
+	}
+	h.Query = time.Since(start)
+	d.filteredLog("db.health.query", tx.tid, err, h.Query, "SELECT 1")
+
+	return h, err
+}
+
 // Close closes the database and prevents new queries from starting.
 // Close then waits for all queries that have started processing on the server to finish.
 func (d *DB) Close() (err error) {
@@ -4,7 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/brunotm/norm/statement"
@@ -303,3 +309,1534 @@ func TestTxPrepareExecSimple(t *testing.T) {
 		t.Fatalf("mock expectations failed: %s", err)
 	}
 }
+
+func TestTxIterate(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,quantity FROM parts").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "quantity"}).
+			AddRow("part01", 10).
+			AddRow("part02", 20).
+			AddRow("part03", 12),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type part struct {
+		ID       string
+		Quantity int64
+	}
+
+	var total int64
+	err = tx.Iterate(statement.Select().Columns("id", "quantity").From("parts"),
+		func(scan func(dst interface{}) error) error {
+			p := &part{}
+			if err := scan(p); err != nil {
+				return err
+			}
+			total += p.Quantity
+			return nil
+		})
+
+	if err != nil {
+		t.Fatalf("error iterating norm/database.DB query: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if total != 42 {
+		t.Fatalf("expected total quantity of 42, got: %d", total)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxInsertMany(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare("INSERT INTO users(id,name,email,role) VALUES (?,?,?,?)").WillBeClosed()
+	prep.ExpectExec().WithArgs("123abc", "john doe", "johnd@email.com", "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs("123abcd", "jane doe", "janed@email.com", "user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs("123abcde", "susan vix", "susanv@email.com", "moderator").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	n, err := tx.InsertMany("users", []string{"id", "name", "email", "role"}, [][]interface{}{
+		{"123abc", "john doe", "johnd@email.com", "admin"},
+		{"123abcd", "jane doe", "janed@email.com", "user"},
+		{"123abcde", "susan vix", "susanv@email.com", "moderator"},
+	})
+
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB insert many: %s", err)
+	}
+
+	if n != 3 {
+		t.Fatalf("expected 3 rows affected, got: %d", n)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxInsertManyOrdinal(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+	db.SetDialect(statement.PostgresDialect)
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare("INSERT INTO users(id,name,email,role) VALUES ($1,$2,$3,$4)").WillBeClosed()
+	prep.ExpectExec().WithArgs("123abc", "john doe", "johnd@email.com", "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs("123abcd", "jane doe", "janed@email.com", "user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	n, err := tx.InsertMany("users", []string{"id", "name", "email", "role"}, [][]interface{}{
+		{"123abc", "john doe", "johnd@email.com", "admin"},
+		{"123abcd", "jane doe", "janed@email.com", "user"},
+	})
+
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB insert many: %s", err)
+	}
+
+	if n != 2 {
+		t.Fatalf("expected 2 rows affected, got: %d", n)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecReturning(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users(name,email,role) VALUES ('john doe','johnd@email.com','admin') RETURNING *").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "email", "role"}).
+				AddRow(1, "john doe", "johnd@email.com", "admin"),
+		)
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID    int64
+		Name  string
+		Email string
+		Role  string
+	}
+
+	insert := statement.Insert().Into("users").Columns("name", "email", "role").
+		Values("john doe", "johnd@email.com", "admin").Returning("*")
+
+	u := user{}
+	if err = tx.ExecReturning(&u, insert); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if u.ID != 1 || u.Name != "john doe" || u.Email != "johnd@email.com" || u.Role != "admin" {
+		t.Fatalf("unexpected returned record: %#v", u)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBStats(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, nil)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Minute)
+	db.SetConnMaxIdleTime(time.Second)
+
+	stats := db.Stats()
+	if stats.MaxOpenConnections != 10 {
+		t.Fatalf("expected 10 max open connections, got: %d", stats.MaxOpenConnections)
+	}
+
+	if stats != mdb.Stats() {
+		t.Fatalf("expected stats: %#v, got: %#v", mdb.Stats(), stats)
+	}
+}
+
+func TestDBHealthCheck(t *testing.T) {
+	mdb, mock, err := sqlmock.New(
+		sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		sqlmock.MonitorPingsOption(true),
+	)
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectPing()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+	mock.ExpectRollback()
+
+	h, err := db.HealthCheck(context.Background(), true)
+	if err != nil {
+		t.Fatalf("error checking database health: %s", err)
+	}
+
+	if h.Ping <= 0 || h.Query <= 0 {
+		t.Fatalf("expected ping and query durations, got: %#v", h)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryMap(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name,email FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "email"}).
+			AddRow(1, "john doe", "johnd@email.com").
+			AddRow(2, "jane doe", "janed@email.com"),
+	)
+	mock.ExpectQuery("SELECT email,name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"email", "name"}).
+			AddRow("johnd@email.com", "john doe").
+			AddRow("janed@email.com", "jane doe"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		Name  string
+		Email string
+	}
+
+	var users map[int]user
+	if err = tx.QueryMap(&users, "id", statement.Select().Columns("id", "name", "email").From("users")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	expectedUsers := map[int]user{
+		1: {Name: "john doe", Email: "johnd@email.com"},
+		2: {Name: "jane doe", Email: "janed@email.com"},
+	}
+
+	if !reflect.DeepEqual(users, expectedUsers) {
+		t.Fatalf("expected: %#v, got: %#v", expectedUsers, users)
+	}
+
+	var names map[string]string
+	if err = tx.QueryMap(&names, "email", statement.Select().Columns("email", "name").From("users")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	expectedNames := map[string]string{"johnd@email.com": "john doe", "janed@email.com": "jane doe"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("expected: %#v, got: %#v", expectedNames, names)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryCacheInvalidation(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,role FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "role"}).AddRow("123abc", "admin"),
+	)
+	mock.ExpectExec("UPDATE users SET role = 'user' WHERE id = '123abc'").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id,role FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "role"}).AddRow("123abc", "user"),
+	)
+	mock.ExpectQuery("SELECT id,role FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "role"}).AddRow("123abc", "user"),
+	)
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Role string
+	}
+
+	query := statement.Select().Columns("id", "role").From("users")
+
+	var users []user
+	if err = tx.QueryCache(&users, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("role", "user").Where("id = ?", "123abc")); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	// the update must invalidate the cache and hit the database
+	users = nil
+	if err = tx.QueryCache(&users, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if len(users) != 1 || users[0].Role != "user" {
+		t.Fatalf("expected fresh query results, got: %#v", users)
+	}
+
+	// explicitly clearing the cache must hit the database
+	tx.ClearCache()
+	users = nil
+	if err = tx.QueryCache(&users, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryNoCache(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT random() AS value").WillReturnRows(
+		sqlmock.NewRows([]string{"value"}).AddRow(0.1),
+	)
+	mock.ExpectQuery("SELECT random() AS value").WillReturnRows(
+		sqlmock.NewRows([]string{"value"}).AddRow(0.2),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	query := statement.Select().Columns("random() AS value").NoCache()
+
+	var values []float64
+	if err = tx.QueryCache(&values, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	// running the query the 2nd time must hit the database
+	values = nil
+	if err = tx.QueryCache(&values, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if len(values) != 1 || values[0] != 0.2 {
+		t.Fatalf("expected fresh query results, got: %#v", values)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxUnderlying(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("NOTIFY users, 'created'").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Underlying().ExecContext(context.Background(), "NOTIFY users, 'created'"); err != nil {
+		t.Fatalf("error executing on the underlying transaction: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBStatementTimeout(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+	db.SetStatementTimeout(5 * time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = 5000").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec("SET LOCAL statement_timeout = 60000").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.SetStatementTimeout(time.Minute); err != nil {
+		t.Fatalf("error setting transaction statement timeout: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	// disabled statement timeout
+	db.SetStatementTimeout(0)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	if tx, err = db.Read(context.Background(), ""); err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExists(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	query := `SELECT EXISTS (SELECT id FROM users WHERE role = 'admin' LIMIT 1 OFFSET 0)`
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectCommit()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	stmt := statement.Select().Columns("id").From("users").Where("role = ?", "admin").OrderDesc("id").Limit(1)
+
+	for _, expected := range []bool{true, false} {
+		exists, err := tx.Exists(stmt)
+		if err != nil {
+			t.Fatalf("error checking existence: %s", err)
+		}
+
+		if exists != expected {
+			t.Fatalf("expected exists: %t, got: %t", expected, exists)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBSlowQueryThreshold(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var logged []string
+	logger := func(message, tid string, err error, d time.Duration, query string) {
+		logged = append(logged, message+": "+query)
+	}
+
+	db, err := New(mdb, sql.LevelSerializable, logger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+	db.SetSlowQueryThreshold(50 * time.Millisecond)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id FROM roles").WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int
+	if err = tx.QuerySQL(&ids, "SELECT id FROM users"); err != nil {
+		t.Fatalf("error querying users: %s", err)
+	}
+
+	if err = tx.QuerySQL(&ids, "SELECT id FROM roles"); err != nil {
+		t.Fatalf("error querying roles: %s", err)
+	}
+
+	// failed operations are always logged
+	if err = tx.QuerySQL(&ids, "SELECT id FROM groups"); err == nil {
+		t.Fatalf("expected error querying groups")
+	}
+
+	_ = tx.Rollback()
+
+	if len(logged) != 2 || !strings.HasSuffix(logged[0], "SELECT id FROM roles") ||
+		!strings.HasSuffix(logged[1], "SELECT id FROM groups") {
+		t.Fatalf("expected only slow and failed operations to be logged, got: %v", logged)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxInsertManyReturning(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO users(name,role) VALUES ('john doe','admin'),('jane doe','user'),('susan vix','moderator') RETURNING id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int64
+	err = tx.InsertManyReturning(&ids, "users", []string{"name", "role"}, [][]interface{}{
+		{"john doe", "admin"},
+		{"jane doe", "user"},
+		{"susan vix", "moderator"},
+	}, "id")
+
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB insert many returning: %s", err)
+	}
+
+	if expected := []int64{1, 2, 3}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, ids)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecReturningExpr(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET role = 'admin' WHERE id = 123 RETURNING id,now() AS updated_at`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(123, now))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID        int64
+		UpdatedAt time.Time
+	}
+
+	var u user
+	err = tx.ExecReturning(&u, statement.Update().Table("users").Set("role", "admin").Where("id = ?", 123).
+		Returning("id").ReturningExpr("now() AS updated_at"))
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB exec returning: %s", err)
+	}
+
+	if u.ID != 123 || !u.UpdatedAt.Equal(now) {
+		t.Fatalf("unexpected returned values: %#v", u)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBQueryRewriter(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	db.SetQueryRewriter(func(ctx context.Context, query string) (string, error) {
+		if strings.HasPrefix(query, "DELETE") {
+			return "", fmt.Errorf("delete statements are not allowed")
+		}
+		return query + " /* tenant: acme */", nil
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users /* tenant: acme */").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE users SET active = false /* tenant: acme */").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users")); err != nil {
+		t.Fatalf("error querying users: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("active", false)); err != nil {
+		t.Fatalf("error updating users: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("users")); err == nil {
+		t.Fatalf("expected rewriter to reject the delete statement")
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBWaitReady(t *testing.T) {
+	mdb, mock, err := sqlmock.New(
+		sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		sqlmock.MonitorPingsOption(true),
+	)
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	errRefused := fmt.Errorf("connection refused")
+
+	// the first pings fail and the last succeeds
+	mock.ExpectPing().WillReturnError(errRefused)
+	mock.ExpectPing().WillReturnError(errRefused)
+	mock.ExpectPing()
+
+	if err = db.WaitReady(context.Background(), 3, time.Millisecond); err != nil {
+		t.Fatalf("expected database to be ready, got: %s", err)
+	}
+
+	// the pings fail until the attempts are exhausted
+	mock.ExpectPing().WillReturnError(errRefused)
+	mock.ExpectPing().WillReturnError(errRefused)
+
+	if err = db.WaitReady(context.Background(), 2, time.Millisecond); !errors.Is(err, errRefused) {
+		t.Fatalf("expected connection refused error, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	// a zero backoff is rejected instead of pinging in a hot loop
+	if err = db.WaitReady(context.Background(), 0, 0); err == nil {
+		t.Fatalf("expected invalid backoff error")
+	}
+
+	// unlimited attempts stop when the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectPing().WillReturnError(errRefused)
+
+	errc := make(chan error, 1)
+	go func() { errc <- db.WaitReady(ctx, 0, time.Hour) }()
+
+	// the failed ping is followed by an hour long backoff
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err = <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context canceled error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected WaitReady to return after the context was cancelled")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryNoRows(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = 'missing'").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = 'missing'").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery("UPDATE users SET name = 'john' WHERE id = 'missing' RETURNING id,name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectPrepare("SELECT id,name FROM users WHERE id = ?").
+		ExpectQuery().WithArgs("missing").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	stmt := statement.Select().Columns("id", "name").From("users").Where("id = ?", "missing")
+
+	var u user
+	if err = tx.Query(&u, stmt); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a struct destination, got: %v", err)
+	}
+
+	var users []user
+	if err = tx.Query(&users, stmt); err != nil || len(users) != 0 {
+		t.Fatalf("expected no error and no rows for a slice destination, got: %v, %#v", err, users)
+	}
+
+	// ExecReturning and prepared statement queries load a struct destination in the same way
+	update := statement.Update().Table("users").Set("name", "john").Where("id = ?", "missing").Returning("id", "name")
+	if err = tx.ExecReturning(&u, update); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a struct destination from ExecReturning, got: %v", err)
+	}
+
+	prepared, err := tx.Prepare("SELECT id,name FROM users WHERE id = ?")
+	if err != nil {
+		t.Fatalf("error preparing statement: %s", err)
+	}
+
+	if err = prepared.Query(&u, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a struct destination from a prepared statement, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxSetRole(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET LOCAL ROLE "app_readonly"`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`SET LOCAL ROLE "weird""role"`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.SetRole("app_readonly"); err != nil {
+		t.Fatalf("error setting transaction role: %s", err)
+	}
+
+	if err = tx.SetRole(`weird"role`); err != nil {
+		t.Fatalf("error setting transaction role: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestNilDBAndStatement(t *testing.T) {
+	if _, err := New(nil, sql.LevelSerializable, DefaultLogger); err == nil {
+		t.Fatalf("expected error creating norm/database.DB with a nil *sql.DB")
+	}
+
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(nil); err == nil || err.Error() != "database: nil statement" {
+		t.Fatalf("expected nil statement error on Exec, got: %v", err)
+	}
+
+	var ids []string
+	var stmt *statement.SelectStatement
+	if err = tx.Query(&ids, stmt); err == nil || err.Error() != "database: nil statement" {
+		t.Fatalf("expected nil statement error on Query, got: %v", err)
+	}
+
+	if _, err = tx.Exists(nil); err == nil {
+		t.Fatalf("expected nil statement error on Exists")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecMany(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users(id,name) VALUES (1,'john')`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO users(id,name) VALUES (2,'jane'),(3,'joe')`).WillReturnResult(sqlmock.NewResult(3, 2))
+	mock.ExpectExec(`INSERT INTO users(id,name) VALUES (4,'jack') ON CONFLICT DO NOTHING`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users(id,name) VALUES (5,'jill')`).WillReturnError(fmt.Errorf("duplicate key"))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	results, err := tx.ExecMany([]statement.Statement{
+		statement.Insert().Into("users").Columns("id", "name").Values(1, "john"),
+		statement.Insert().Into("users").Columns("id", "name").Values(2, "jane").Values(3, "joe"),
+		statement.Insert().Into("users").Columns("id", "name").Values(4, "jack").OnConflict("DO NOTHING"),
+	})
+	if err != nil {
+		t.Fatalf("error executing statements: %s", err)
+	}
+
+	expected := []int64{1, 2, 0}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got: %d", len(expected), len(results))
+	}
+
+	for x := range results {
+		affected, err := results[x].RowsAffected()
+		if err != nil {
+			t.Fatalf("error getting rows affected: %s", err)
+		}
+
+		if affected != expected[x] {
+			t.Fatalf("expected %d rows affected for statement %d, got: %d", expected[x], x, affected)
+		}
+	}
+
+	results, err = tx.ExecMany([]statement.Statement{
+		statement.Insert().Into("users").Columns("id", "name").Values(5, "jill"),
+		statement.Insert().Into("users").Columns("id", "name").Values(6, "jim"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "statement 0") {
+		t.Fatalf("expected error for statement 0, got: %v", err)
+	}
+
+	if len(results) != 0 {
+		t.Fatalf("expected no results, got: %d", len(results))
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBLogRedactor(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var logged []string
+	logger := func(message, tid string, err error, d time.Duration, query string) {
+		logged = append(logged, message+": "+query)
+	}
+
+	db, err := New(mdb, sql.LevelSerializable, logger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	password := regexp.MustCompile(`password = '[^']*'`)
+	db.SetLogRedactor(func(query string, args []interface{}) string {
+		if args == nil {
+			return password.ReplaceAllString(query, "password = '***'")
+		}
+
+		// mask all prepared statement arguments
+		return fmt.Sprintf("%s %d args", query, len(args))
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET password = 's3cr3t' WHERE id = 1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`UPDATE users SET password = ? WHERE id = ?`).
+		ExpectExec().WithArgs("s3cr3t", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("password", "s3cr3t").Where("id = ?", 1)); err != nil {
+		t.Fatalf("error updating user: %s", err)
+	}
+
+	stmt, err := tx.Prepare(`UPDATE users SET password = ? WHERE id = ?`)
+	if err != nil {
+		t.Fatalf("error preparing statement: %s", err)
+	}
+
+	if _, err = stmt.Exec("s3cr3t", 2); err != nil {
+		t.Fatalf("error executing prepared statement: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	expected := []string{
+		"db.tx.exec: UPDATE users SET password = '***' WHERE id = 1",
+		"db.tx.stmt.exec: UPDATE users SET password = ? WHERE id = ? 2 args",
+	}
+
+	for _, e := range expected {
+		var found bool
+		for _, l := range logged {
+			found = found || l == e
+		}
+
+		if !found {
+			t.Fatalf("expected log entry: %s, got: %v", e, logged)
+		}
+	}
+
+	for _, l := range logged {
+		if strings.Contains(l, "s3cr3t") {
+			t.Fatalf("unexpected password in log entry: %s", l)
+		}
+	}
+}
+
+func TestTxQueryScalar(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT(*) FROM users WHERE role = 'admin'`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+	mock.ExpectQuery(`SELECT max(id) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id,name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "john"))
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = 0`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var count int
+	if err = tx.QueryScalar(&count, statement.Select().Columns("COUNT(*)").From("users").Where("role = ?", "admin")); err != nil {
+		t.Fatalf("error querying count: %s", err)
+	}
+
+	if count != 42 {
+		t.Fatalf("expected count: 42, got: %d", count)
+	}
+
+	var max sql.NullInt64
+	if err = tx.QueryScalar(&max, statement.Select().Columns("max(id)").From("users")); err != nil {
+		t.Fatalf("error querying max: %s", err)
+	}
+
+	if max.Valid {
+		t.Fatalf("expected null max, got: %d", max.Int64)
+	}
+
+	var id int
+	if err = tx.QueryScalar(&id, statement.Select().Columns("id", "name").From("users")); err == nil {
+		t.Fatalf("expected error for multiple columns")
+	}
+
+	if err = tx.QueryScalar(&id, statement.Select().Columns("id").From("users")); err == nil {
+		t.Fatalf("expected error for multiple rows")
+	}
+
+	if err = tx.QueryScalar(&id, statement.Select().Columns("id").From("users").Where("id = ?", 0)); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxInsertRecords(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	users := make([]user, 2500)
+	for x := range users {
+		users[x] = user{ID: x, Name: fmt.Sprintf("user%d", x)}
+	}
+
+	mock.ExpectBegin()
+	for _, chunk := range [][2]int{{0, 1000}, {1000, 2000}, {2000, 2500}} {
+		stmt := statement.Insert().Into("users")
+		for x := chunk[0]; x < chunk[1]; x++ {
+			stmt.Record(users[x])
+		}
+
+		query, err := stmt.String()
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, int64(chunk[1]-chunk[0])))
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	n, err := tx.InsertRecords("users", users, 1000)
+	if err != nil {
+		t.Fatalf("error inserting records: %s", err)
+	}
+
+	if n != 2500 {
+		t.Fatalf("expected 2500 rows affected, got: %d", n)
+	}
+
+	if _, err = tx.InsertRecords("users", users[0], 1000); err == nil {
+		t.Fatalf("expected error inserting records from a non slice value")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxCursorHoldsTx(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, nil)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM parts").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("part01").AddRow("part02"),
+	)
+	mock.ExpectExec("DELETE FROM parts").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT id FROM parts").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("part03"))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	cursor, err := tx.Cursor(statement.Select().Columns("id").From("parts"))
+	if err != nil {
+		t.Fatalf("error opening cursor: %s", err)
+	}
+
+	// concurrent operations fail while the cursor is open
+	errCh := make(chan error)
+	go func() {
+		_, err := tx.Exec(statement.Delete().From("parts"))
+		errCh <- err
+	}()
+
+	if err = <-errCh; !errors.Is(err, ErrCursorOpen) {
+		t.Fatalf("expected ErrCursorOpen, got: %v", err)
+	}
+
+	var ids []string
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("parts")); !errors.Is(err, ErrCursorOpen) {
+		t.Fatalf("expected ErrCursorOpen, got: %v", err)
+	}
+
+	if _, err = tx.Cursor(statement.Select().Columns("id").From("parts")); !errors.Is(err, ErrCursorOpen) {
+		t.Fatalf("expected ErrCursorOpen, got: %v", err)
+	}
+
+	// the cursor releases the transaction once fully iterated
+	for cursor.Next() {
+		var id string
+		if err = cursor.Scan(&id); err != nil {
+			t.Fatalf("error scanning cursor: %s", err)
+		}
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("parts")); err != nil {
+		t.Fatalf("error executing after cursor iteration: %s", err)
+	}
+
+	if err = cursor.Close(); err != nil {
+		t.Fatalf("error closing cursor: %s", err)
+	}
+
+	// the Iterate callback can't use the transaction held by the cursor
+	err = tx.Iterate(statement.Select().Columns("id").From("parts"), func(scan func(dst interface{}) error) error {
+		_, err := tx.Exec(statement.Delete().From("parts"))
+		return err
+	})
+
+	if !errors.Is(err, ErrCursorOpen) {
+		t.Fatalf("expected ErrCursorOpen from the Iterate callback, got: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %s", err)
+	}
+}
+
+func TestTxQueryRaw(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, nil)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,quantity FROM parts WHERE quantity > 5").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "quantity"}).
+			AddRow("part01", 10).
+			AddRow("part02", 20),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	rows, err := tx.QueryRaw(statement.Select().Columns("id", "quantity").From("parts").Where("quantity > ?", 5))
+	if err != nil {
+		t.Fatalf("error querying: %s", err)
+	}
+
+	var ids []string
+	var total int64
+	for rows.Next() {
+		var id string
+		var quantity int64
+		if err = rows.Scan(&id, &quantity); err != nil {
+			t.Fatalf("error scanning rows: %s", err)
+		}
+		ids = append(ids, id)
+		total += quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("error iterating rows: %s", err)
+	}
+
+	if err = rows.Close(); err != nil {
+		t.Fatalf("error closing rows: %s", err)
+	}
+
+	if expected := []string{"part01", "part02"}; !reflect.DeepEqual(ids, expected) || total != 30 {
+		t.Fatalf("expected: %v and total 30, got: %v and total %d", expected, ids, total)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %s", err)
+	}
+}
+
+func TestCursorReset(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, nil)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "quantity"}).AddRow("part01", 10).AddRow("part02", 20)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,quantity FROM parts").WillReturnRows(rows())
+	mock.ExpectQuery("SELECT id,quantity FROM parts").WillReturnRows(rows())
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	cursor, err := tx.Cursor(statement.Select().Columns("id", "quantity").From("parts"))
+	if err != nil {
+		t.Fatalf("error opening cursor: %s", err)
+	}
+	defer cursor.Close()
+
+	type part struct {
+		ID       string
+		Quantity int64
+	}
+
+	var total int64
+	for cursor.Next() {
+		p := &part{}
+		if err = cursor.Scan(p); err != nil {
+			t.Fatalf("error scanning cursor: %s", err)
+		}
+		total += p.Quantity
+	}
+
+	if err = cursor.Reset(); err != nil {
+		t.Fatalf("error resetting cursor: %s", err)
+	}
+
+	// the second pass can scan into a different type
+	var ids []string
+	for cursor.Next() {
+		m := map[string]interface{}{}
+		if err = cursor.Scan(&m); err != nil {
+			t.Fatalf("error scanning cursor: %s", err)
+		}
+		ids = append(ids, m["id"].(string))
+	}
+
+	if err = cursor.Err(); err != nil {
+		t.Fatalf("error iterating cursor: %s", err)
+	}
+
+	if expected := []string{"part01", "part02"}; total != 30 || !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected total: 30, ids: %v, got total: %d, ids: %v", expected, total, ids)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %s", err)
+	}
+}
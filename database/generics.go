@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+
+	"github.com/brunotm/norm/statement"
+)
+
+// QueryRows executes a query that returns rows, loading them into a []T.
+func QueryRows[T any](tx *Tx, stmt statement.Statement) (rows []T, err error) {
+	if err = tx.Query(&rows, stmt); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// QueryOne executes a query that returns rows, loading the first row into a T.
+// It returns sql.ErrNoRows if there are no rows.
+func QueryOne[T any](tx *Tx, stmt statement.Statement) (row T, err error) {
+	err = tx.Query(&row, stmt)
+	return row, err
+}
+
+// QueryChan executes a query that returns rows, streaming each row scanned into a T to ch
+// through a Cursor. Rows are read as they are received by ch, so a slow receiver applies backpressure
+// to the result set reading.
+//
+// QueryChan blocks until all rows are sent, an error happens or the given context is done, returning
+// the context error in the later case. The ch channel is always closed before QueryChan returns.
+func QueryChan[T any](ctx context.Context, tx *Tx, stmt statement.Statement, ch chan<- T) (err error) {
+	defer close(ch)
+
+	cursor, err := tx.Cursor(stmt)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		var row T
+		if err = cursor.Scan(&row); err != nil {
+			return err
+		}
+
+		select {
+		case ch <- row:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	return cursor.Close()
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/brunotm/norm/statement"
+)
+
+func TestQueryRows(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).
+			AddRow("123abc", "john doe").
+			AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("123abc").AddRow("123abcd"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	users, err := QueryRows[user](tx, statement.Select().Columns("id", "name").From("users"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	expectedUsers := []user{{ID: "123abc", Name: "john doe"}, {ID: "123abcd", Name: "jane doe"}}
+	if !reflect.DeepEqual(users, expectedUsers) {
+		t.Fatalf("expected: %#v, got: %#v", expectedUsers, users)
+	}
+
+	ids, err := QueryRows[string](tx, statement.Select().Columns("id").From("users"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if expectedIDs := []string{"123abc", "123abcd"}; !reflect.DeepEqual(ids, expectedIDs) {
+		t.Fatalf("expected: %#v, got: %#v", expectedIDs, ids)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestQueryOne(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = '123abc'").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe"),
+	)
+	mock.ExpectQuery("SELECT count(*) FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"count"}).AddRow(2),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	u, err := QueryOne[user](tx, statement.Select().Columns("id", "name").From("users").Where("id = ?", "123abc"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if expected := (user{ID: "123abc", Name: "john doe"}); u != expected {
+		t.Fatalf("expected: %#v, got: %#v", expected, u)
+	}
+
+	count, err := QueryOne[int64](tx, statement.Select().Columns("count(*)").From("users"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("expected count 2, got: %d", count)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestQueryChan(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).
+			AddRow("123abc", "john doe").
+			AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2").AddRow("3").AddRow("4"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	users := make(chan user)
+	done := make(chan error, 1)
+	go func() {
+		done <- QueryChan(context.Background(), tx, statement.Select().Columns("id", "name").From("users"), users)
+	}()
+
+	var received []user
+	for u := range users {
+		received = append(received, u)
+	}
+
+	if err = <-done; err != nil {
+		t.Fatalf("error streaming rows: %s", err)
+	}
+
+	expectedUsers := []user{{ID: "123abc", Name: "john doe"}, {ID: "123abcd", Name: "jane doe"}}
+	if !reflect.DeepEqual(received, expectedUsers) {
+		t.Fatalf("expected: %#v, got: %#v", expectedUsers, received)
+	}
+
+	// cancel after receiving the first row
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ids := make(chan string)
+	go func() {
+		done <- QueryChan(ctx, tx, statement.Select().Columns("id").From("users"), ids)
+	}()
+
+	if id := <-ids; id != "1" {
+		t.Fatalf("expected id: 1, got: %s", id)
+	}
+	cancel()
+
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+
+	if _, ok := <-ids; ok {
+		t.Fatalf("expected closed channel")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
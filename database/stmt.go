@@ -2,15 +2,15 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/brunotm/norm/internal/scan"
 )
 
 type Stmt struct {
-	tx   *Tx
-	stmt *sql.Stmt
+	tx    *Tx
+	stmt  *sql.Stmt
+	query string
 }
 
 // Close closes the statement.
@@ -18,7 +18,7 @@ func (s *Stmt) Close() (err error) {
 	start := time.Now()
 	err = s.stmt.Close()
 
-	s.tx.log("db.tx.stmt.close", s.tx.tid, err, time.Since(start), "")
+	s.tx.queryLog("db.tx.stmt.close", s.tx.tid, err, time.Since(start), "")
 	return err
 }
 
@@ -26,17 +26,26 @@ func (s *Stmt) Close() (err error) {
 // returns a Result summarizing the effect of the statement.
 func (s *Stmt) Exec(args ...interface{}) (r sql.Result, err error) {
 	start := time.Now()
+
+	if err = s.tx.cursorOpen(); err != nil {
+		return nil, err
+	}
 	r, err = s.stmt.ExecContext(s.tx.ctx, args...)
+	s.tx.ClearCache()
 
-	s.tx.log("db.tx.stmt.exec", s.tx.tid, err, time.Since(start), fmt.Sprintf("%+v", args))
+	s.tx.logArgs("db.tx.stmt.exec", err, time.Since(start), s.query, args)
 	return r, err
 }
 
-// Query executes a prepared query statement with the given arguments
-// and returns the query results as a *Rows.
+// Query executes a prepared query statement with the given arguments and scans the results into dst as in Tx.Query.
+// A non slice dst is loaded from the first row, returning sql.ErrNoRows if there are no rows.
 func (s *Stmt) Query(dst interface{}, args ...interface{}) (err error) {
 	start := time.Now()
 
+	if err = s.tx.cursorOpen(); err != nil {
+		return err
+	}
+
 	r, err := s.stmt.QueryContext(s.tx.ctx, args...)
 	if err != nil {
 		return err
@@ -44,7 +53,7 @@ func (s *Stmt) Query(dst interface{}, args ...interface{}) (err error) {
 	defer r.Close()
 
 	_, err = scan.Load(r, dst)
-	s.tx.log("db.tx.stmt.query", s.tx.tid, err, time.Since(start), fmt.Sprintf("%+v", args))
+	s.tx.logArgs("db.tx.stmt.query", err, time.Since(start), s.query, args)
 	return err
 
 }
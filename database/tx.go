@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"hash/maphash"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,27 +16,184 @@ import (
 
 // Tx represents a database transaction
 type Tx struct {
-	mu    sync.Mutex
-	tid   string
-	log   Logger
-	done  bool
-	tx    *sql.Tx
-	ctx   context.Context
-	hash  maphash.Hash
-	cache map[uint64]reflect.Value
+	mu       sync.Mutex
+	tid      string
+	queryLog Logger // logs queries, redacted by the LogRedactor when set
+	argsLog  Logger // logs prepared statement args through logArgs, redacted by the LogRedactor when set, raw otherwise
+	done     bool
+	tx       *sql.Tx
+	ctx      context.Context
+	hash     maphash.Hash
+	cache    map[uint64]reflect.Value
+	rewrite  QueryRewriter
+	redact   LogRedactor
+	dialect  *statement.Dialect
+	cursor   *Cursor
 }
 
 // Prepare creates a prepared statement for use within a transaction.
 func (t *Tx) Prepare(query string) (stmt *Stmt, err error) {
 	start := time.Now()
 
+	if err = t.cursorOpen(); err != nil {
+		return nil, err
+	}
+
+	if query, err = t.rewriteQuery(query); err != nil {
+		return nil, err
+	}
+
 	s, err := t.tx.PrepareContext(t.ctx, query)
-	t.log("db.tx.prepare", t.tid, err, time.Since(start), query)
+	t.queryLog("db.tx.prepare", t.tid, err, time.Since(start), query)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Stmt{tx: t, stmt: s}, err
+	return &Stmt{tx: t, stmt: s, query: query}, err
+}
+
+// DefaultInsertChunkSize is the number of records inserted per statement by InsertRecords
+// when the given chunk size is not greater than zero.
+const DefaultInsertChunkSize = 1000
+
+// InsertRecords inserts the given slice of structs into table in chunks of up to chunkSize records,
+// building and executing a multi row `INSERT INTO table(columns) VALUES (...),(...)` statement per chunk
+// within the transaction, so very large inserts don't need to be built as a single query.
+// Columns are derived from the struct fields as in statement.InsertStatement.Record.
+// It returns the total number of rows affected, stopping at the first chunk that fails.
+func (t *Tx) InsertRecords(table string, records interface{}, chunkSize int) (n int64, err error) {
+	v := reflect.Indirect(reflect.ValueOf(records))
+	if v.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("database: records must be a slice, got: %T", records)
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultInsertChunkSize
+	}
+
+	for start := 0; start < v.Len(); start += chunkSize {
+		end := start + chunkSize
+		if end > v.Len() {
+			end = v.Len()
+		}
+
+		stmt := statement.Insert().Into(table)
+		for x := start; x < end; x++ {
+			stmt.Record(v.Index(x).Interface())
+		}
+
+		r, err := t.Exec(stmt)
+		if err != nil {
+			return n, fmt.Errorf("database: inserting records %d to %d: %w", start, end-1, err)
+		}
+
+		affected, err := r.RowsAffected()
+		if err != nil {
+			return n, err
+		}
+		n += affected
+	}
+
+	return n, nil
+}
+
+// InsertMany prepares a single `INSERT INTO table(columns) VALUES (...)` statement and executes it
+// once for each of the given rows within the transaction, returning the total number of rows affected.
+//
+// The statement is prepared with `$N` placeholders when the DB dialect uses ordinal placeholders,
+// e.g. PostgreSQL, or `?` placeholders otherwise, and the row values are passed as arguments
+// to the database driver.
+func (t *Tx) InsertMany(table string, columns []string, rows [][]interface{}) (n int64, err error) {
+	build := statement.StringArgs
+	if t.dialect != nil && t.dialect.OrdinalPlaceholders {
+		build = statement.StringOrdinalArgs
+	}
+
+	query, _, err := build(statement.Insert().Into(table).Columns(columns...).
+		Values(make([]interface{}, len(columns))...))
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := t.Prepare(query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for x := 0; x < len(rows); x++ {
+		if len(rows[x]) != len(columns) {
+			return n, fmt.Errorf("database: invalid number of values for row %d: %d, expected: %d",
+				x, len(rows[x]), len(columns))
+		}
+
+		r, err := stmt.Exec(rows[x]...)
+		if err != nil {
+			return n, err
+		}
+
+		affected, err := r.RowsAffected()
+		if err != nil {
+			return n, err
+		}
+		n += affected
+	}
+
+	return n, nil
+}
+
+// SetStatementTimeout sets a server side statement timeout for the remaining of the transaction
+// with `SET LOCAL statement_timeout`, which is PostgreSQL specific. A zero duration disables the timeout.
+func (t *Tx) SetStatementTimeout(d time.Duration) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SET LOCAL statement_timeout = %d", d.Milliseconds())
+	_, err = t.tx.ExecContext(t.ctx, query)
+
+	t.queryLog("db.tx.statement_timeout", t.tid, err, time.Since(start), query)
+	return err
+}
+
+// InsertManyReturning executes a single multi-row `INSERT INTO table(columns) VALUES (...),(...)
+// RETURNING returning` statement for the given rows, scanning all returned rows into dst as in
+// ExecReturning, e.g. the generated ids into a []int64.
+//
+// The database is not required to return the rows in the same order they were provided
+// (PostgreSQL doesn't guarantee it), so callers needing to correlate the returned values with the
+// input rows should also return a column identifying each row.
+func (t *Tx) InsertManyReturning(dst interface{}, table string, columns []string, rows [][]interface{}, returning ...string) (err error) {
+	stmt := statement.Insert().Into(table).Columns(columns...).Returning(returning...)
+	for x := 0; x < len(rows); x++ {
+		stmt.Values(rows[x]...)
+	}
+
+	return t.ExecReturning(dst, stmt)
+}
+
+// SetRole sets the role for the remaining of the transaction with `SET LOCAL ROLE "role"`,
+// which is PostgreSQL specific and is reset when the transaction commits or rolls back.
+// The role is quoted as an identifier, so it is case sensitive.
+func (t *Tx) SetRole(role string) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	query := `SET LOCAL ROLE "` + strings.ReplaceAll(role, `"`, `""`) + `"`
+	_, err = t.tx.ExecContext(t.ctx, query)
+	t.clearCache()
+
+	t.queryLog("db.tx.role", t.tid, err, time.Since(start), query)
+	return err
 }
 
 // Exec executes a query that doesn't return rows.
@@ -44,17 +202,72 @@ func (t *Tx) Exec(stmt statement.Statement) (r sql.Result, err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	query, err := stmt.String()
+	if err = t.checkCursor(); err != nil {
+		return nil, err
+	}
+
+	query, err := t.build(stmt)
 	if err != nil {
 		return nil, err
 	}
 
 	r, err = t.tx.ExecContext(t.ctx, query)
+	t.clearCache()
 
-	t.log("db.tx.exec", t.tid, err, time.Since(start), query)
+	t.queryLog("db.tx.exec", t.tid, err, time.Since(start), query)
 	return r, err
 }
 
+// ExecMany executes the given statements in order within the transaction returning their results.
+// It stops on the first error, which includes the index of the failing statement, returning the results
+// of the statements executed up to that point.
+func (t *Tx) ExecMany(stmts []statement.Statement) (results []sql.Result, err error) {
+	results = make([]sql.Result, 0, len(stmts))
+
+	for x := 0; x < len(stmts); x++ {
+		r, err := t.Exec(stmts[x])
+		if err != nil {
+			return results, fmt.Errorf("database: statement %d: %w", x, err)
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// ExecReturning executes a query that modifies rows and returns them, like `INSERT ... RETURNING *`,
+// scanning the returned rows into dst as in Query. Columns are mapped to struct fields as with
+// Query, so `RETURNING *` can be scanned directly into the record type. A non slice dst is loaded
+// from the first returned row, returning sql.ErrNoRows if no rows were returned, e.g. when no rows
+// matched an update or an insert conflict was skipped.
+func (t *Tx) ExecReturning(dst interface{}, stmt statement.Statement) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	query, err := t.build(stmt)
+	if err != nil {
+		return err
+	}
+
+	r, err := t.tx.QueryContext(t.ctx, query)
+	if err != nil {
+		t.queryLog("db.tx.exec.returning", t.tid, err, time.Since(start), query)
+		return err
+	}
+	defer r.Close()
+
+	t.clearCache()
+
+	_, err = scan.Load(r, dst)
+	t.queryLog("db.tx.exec.returning", t.tid, err, time.Since(start), query)
+	return err
+}
+
 // ExecSQL is like Exec but accepts a raw SQL statement and values for interpolation
 func (t *Tx) ExecSQL(query string, values ...interface{}) (r sql.Result, err error) {
 	stmt := &statement.Part{Query: query, Values: values}
@@ -62,10 +275,22 @@ func (t *Tx) ExecSQL(query string, values ...interface{}) (r sql.Result, err err
 }
 
 // Query executes a query that returns rows.
+// A non slice dst is loaded from the first row, returning sql.ErrNoRows if there are no rows.
 func (t *Tx) Query(dst interface{}, stmt statement.Statement) (err error) {
 	return t.query(dst, stmt, false)
 }
 
+// Exists reports whether the given select statement matches any row
+// by querying `SELECT EXISTS (stmt)` within the transaction.
+func (t *Tx) Exists(stmt *statement.SelectStatement) (exists bool, err error) {
+	if stmt == nil {
+		return false, fmt.Errorf("database: nil statement")
+	}
+
+	err = t.query(&exists, stmt.Exists(), false)
+	return exists, err
+}
+
 // QuerySQL is like Query but accepts a raw SQL statement and values for interpolation
 func (t *Tx) QuerySQL(dst interface{}, query string, values ...interface{}) (err error) {
 	stmt := &statement.Part{Query: query, Values: values}
@@ -74,6 +299,9 @@ func (t *Tx) QuerySQL(dst interface{}, query string, values ...interface{}) (err
 
 // QueryCache is like Query, but will add query results to or return already cached
 // results from the transaction query cache.
+//
+// The cache is cleared on every Exec or ExecReturning within the transaction, so results
+// are fetched again after writes. It can also be cleared explicitly with ClearCache.
 func (t *Tx) QueryCache(dst interface{}, stmt statement.Statement) (err error) {
 	return t.query(dst, stmt, true)
 }
@@ -84,10 +312,92 @@ func (t *Tx) QueryCacheSQL(dst interface{}, query string, values ...interface{})
 	return t.query(dst, stmt, true)
 }
 
+// QueryMap executes a query that returns rows, loading them into the map pointed by dst
+// keyed by the values of keyColumn. The remaining columns are loaded into the map values,
+// which can be either a struct, map or a scalar when a single column remains.
+func (t *Tx) QueryMap(dst interface{}, keyColumn string, stmt statement.Statement) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	query, err := t.build(stmt)
+	if err != nil {
+		return err
+	}
+
+	r, err := t.tx.QueryContext(t.ctx, query)
+	if err != nil {
+		t.queryLog("db.tx.query.map", t.tid, err, time.Since(start), query)
+		return err
+	}
+	defer r.Close()
+
+	_, err = scan.LoadMap(r, dst, keyColumn)
+	t.queryLog("db.tx.query.map", t.tid, err, time.Since(start), query)
+	return err
+}
+
+// QueryScalar executes a query that must return exactly one row with a single column,
+// e.g. `SELECT COUNT(*) FROM users`, scanning the value into dst, which must be a pointer to a scalar
+// or sql.Scanner. It returns sql.ErrNoRows when there are no rows and an error when more than one
+// row or column is returned.
+func (t *Tx) QueryScalar(dst interface{}, stmt statement.Statement) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	query, err := t.build(stmt)
+	if err != nil {
+		return err
+	}
+
+	r, err := t.tx.QueryContext(t.ctx, query)
+	if err != nil {
+		t.queryLog("db.tx.query.scalar", t.tid, err, time.Since(start), query)
+		return err
+	}
+	defer r.Close()
+
+	err = scanScalar(r, dst)
+	t.queryLog("db.tx.query.scalar", t.tid, err, time.Since(start), query)
+	return err
+}
+
+// QueryRaw executes a query that returns rows and returns the underlying *sql.Rows as an escape hatch
+// for libraries which scan rows themselves, like CSV writers. The caller must close the returned rows
+// and, unlike Cursor, the rows don't hold the transaction, so they must be closed before any other
+// operation within the transaction.
+func (t *Tx) QueryRaw(stmt statement.Statement) (rows *sql.Rows, err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err = t.checkCursor(); err != nil {
+		return nil, err
+	}
+
+	query, err := t.build(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = t.tx.QueryContext(t.ctx, query)
+	t.queryLog("db.tx.query.raw", t.tid, err, time.Since(start), query)
+	return rows, err
+}
+
 func (t *Tx) query(dst interface{}, stmt statement.Statement, cache bool) (err error) {
 	start := time.Now()
 
-	query, err := stmt.String()
+	query, err := t.build(stmt)
 	if err != nil {
 		return err
 	}
@@ -95,6 +405,15 @@ func (t *Tx) query(dst interface{}, stmt statement.Statement, cache bool) (err e
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if err = t.checkCursor(); err != nil {
+		return err
+	}
+
+	// statements marked as non-cacheable always hit the database
+	if c, ok := stmt.(statement.Cacheable); ok && !c.Cacheable() {
+		cache = false
+	}
+
 	var key uint64
 	if cache {
 		if _, err = t.hash.WriteString(query); err != nil {
@@ -109,45 +428,88 @@ func (t *Tx) query(dst interface{}, stmt statement.Statement, cache bool) (err e
 
 			if dstValue.Kind() != reflect.Ptr {
 				err := fmt.Errorf("database: dst must be a pointer type")
-				t.log("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
+				t.queryLog("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
 				return err
 			}
 
 			if dstValue.Elem().Type() != r.Type() {
 				err := fmt.Errorf("database: invalid cached dst type: %s, expected: %s",
 					dstValue.Type().String(), r.Type().String())
-				t.log("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
+				t.queryLog("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
 				return err
 			}
 
-			dstValue.Elem().Set(r)
-			t.log("db.tx.query.cache.get", t.tid, nil, time.Since(start), query)
+			dstValue.Elem().Set(cacheCopy(r))
+			t.queryLog("db.tx.query.cache.get", t.tid, nil, time.Since(start), query)
 			return nil
 		}
 	}
 
 	r, err := t.tx.QueryContext(t.ctx, query)
 	if err != nil {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+		t.queryLog("db.tx.query", t.tid, err, time.Since(start), query)
 		return err
 	}
 	defer r.Close()
 
 	if _, err = scan.Load(r, dst); err != nil {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+		t.queryLog("db.tx.query", t.tid, err, time.Since(start), query)
 		return err
 	}
 
 	if cache {
-		t.cache[key] = reflect.ValueOf(dst).Elem()
-		t.log("db.tx.query.cache.add", t.tid, nil, time.Since(start), query)
+		t.cache[key] = cacheCopy(reflect.ValueOf(dst).Elem())
+		t.queryLog("db.tx.query.cache.add", t.tid, nil, time.Since(start), query)
 	} else {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+		t.queryLog("db.tx.query", t.tid, err, time.Since(start), query)
 	}
 
 	return nil
 }
 
+// ClearCache drops all results from the transaction query cache.
+func (t *Tx) ClearCache() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.clearCache()
+}
+
+// cursorOpen returns ErrCursorOpen if a cursor is open within the transaction.
+func (t *Tx) cursorOpen() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.checkCursor()
+}
+
+// checkCursor returns ErrCursorOpen if a cursor is open within the transaction,
+// must be called with the lock held.
+func (t *Tx) checkCursor() (err error) {
+	if t.cursor != nil {
+		return ErrCursorOpen
+	}
+	return nil
+}
+
+// clearCache drops all cached results, must be called with the lock held.
+func (t *Tx) clearCache() {
+	for key := range t.cache {
+		delete(t.cache, key)
+	}
+}
+
+// Underlying returns the underlying *sql.Tx as an escape hatch for driver specific features
+// not provided by Tx, like `LISTEN/NOTIFY` or `COPY`.
+//
+// Operations done directly on the *sql.Tx are not logged, are not serialized with
+// the Tx operations and do not invalidate the transaction query cache. Callers must not
+// use it concurrently with other Tx methods, should call ClearCache after writes if
+// QueryCache is used, and must always finish the transaction with Tx.Commit or Tx.Rollback.
+func (t *Tx) Underlying() (tx *sql.Tx) {
+	return t.tx
+}
+
 // Commit the transaction.
 func (t *Tx) Commit() (err error) {
 	start := time.Now()
@@ -157,7 +519,7 @@ func (t *Tx) Commit() (err error) {
 	err = t.tx.Commit()
 	t.done = true
 
-	t.log("db.tx.commit", t.tid, err, time.Since(start), "")
+	t.queryLog("db.tx.commit", t.tid, err, time.Since(start), "")
 	return err
 }
 
@@ -174,6 +536,98 @@ func (t *Tx) Rollback() (err error) {
 	err = t.tx.Rollback()
 	t.done = true
 
-	t.log("db.tx.rollback", t.tid, err, time.Since(start), "")
+	t.queryLog("db.tx.rollback", t.tid, err, time.Since(start), "")
 	return err
 }
+
+// build builds the statement query, applying the DB QueryRewriter if set.
+func (t *Tx) build(stmt statement.Statement) (query string, err error) {
+	if isNil(stmt) {
+		return "", fmt.Errorf("database: nil statement")
+	}
+
+	if query, err = stmt.String(); err != nil {
+		return "", err
+	}
+
+	return t.rewriteQuery(query)
+}
+
+// rewriteQuery applies the DB QueryRewriter to the given query if set.
+func (t *Tx) rewriteQuery(query string) (q string, err error) {
+	if t.rewrite == nil {
+		return query, nil
+	}
+
+	start := time.Now()
+	q, err = t.rewrite(t.ctx, query)
+	t.queryLog("db.tx.rewrite", t.tid, err, time.Since(start), q)
+
+	if err != nil {
+		return "", err
+	}
+
+	return q, nil
+}
+
+// cacheCopy returns a copy of the given value for the query cache, so slices loaded in place
+// by subsequent queries do not share their backing array with cached results.
+func cacheCopy(v reflect.Value) (c reflect.Value) {
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		c = reflect.New(v.Type()).Elem()
+		c.Set(v)
+		return c
+	}
+
+	return reflect.AppendSlice(reflect.MakeSlice(v.Type(), 0, v.Len()), v)
+}
+
+// isNil reports whether the given statement is nil or a typed nil pointer.
+func isNil(stmt statement.Statement) bool {
+	if stmt == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(stmt)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// logArgs logs a prepared statement operation with its args, or with the
+// representation of the query and args produced by the DB LogRedactor if set.
+func (t *Tx) logArgs(message string, err error, d time.Duration, query string, args []interface{}) {
+	if t.redact == nil {
+		t.argsLog(message, t.tid, err, d, fmt.Sprintf("%+v", args))
+		return
+	}
+
+	t.argsLog(message, t.tid, err, d, t.redact(query, args))
+}
+
+// scanScalar scans the single column of the single row from the given rows into dst.
+func scanScalar(r *sql.Rows, dst interface{}) (err error) {
+	columns, err := r.Columns()
+	if err != nil {
+		return err
+	}
+
+	if len(columns) != 1 {
+		return fmt.Errorf("database: scalar query returned %d columns, expected: 1", len(columns))
+	}
+
+	if !r.Next() {
+		if err = r.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	if err = r.Scan(dst); err != nil {
+		return err
+	}
+
+	if r.Next() {
+		return fmt.Errorf("database: scalar query returned more than one row")
+	}
+
+	return r.Err()
+}
@@ -4,6 +4,10 @@ import (
 	"sync"
 )
 
+// MaxPooledCap is the maximum capacity of a buffer to be returned to the pool on Release,
+// so buffers grown by very large statements are garbage collected instead of retained by the pool.
+const MaxPooledCap = 64 << 10
+
 var pool = &sync.Pool{
 	New: func() interface{} {
 		return &Buffer{
@@ -56,8 +60,13 @@ func (b *Buffer) Len() int { return len(b.buf) }
 // already written.
 func (b *Buffer) Cap() int { return cap(b.buf) }
 
-// Release releases the buffer making it available for reutilization
+// Release releases the buffer making it available for reutilization.
+// Buffers whose capacity exceeds MaxPooledCap are discarded.
 func (b *Buffer) Release() {
+	if cap(b.buf) > MaxPooledCap {
+		return
+	}
+
 	b.buf = b.buf[:0]
 	pool.Put(b)
 }
@@ -52,3 +52,48 @@ func TestBuffer_WriteByte(t *testing.T) {
 		t.Errorf("expected: %s, got: %s", string(s), buf.String())
 	}
 }
+
+func TestBuffer_ReleaseOversized(t *testing.T) {
+	chunk := make([]byte, 1024)
+
+	buf := New()
+	for buf.Cap() <= MaxPooledCap {
+		_, _ = buf.Write(chunk)
+	}
+	buf.Release()
+
+	for x := 0; x < 10; x++ {
+		b := New()
+		if b == buf || b.Cap() > MaxPooledCap {
+			t.Fatalf("oversized buffer returned from the pool, capacity: %d", b.Cap())
+		}
+		defer b.Release()
+	}
+
+	// buffers within the cap are still pooled and reset
+	buf = New()
+	_, _ = buf.Write(chunk)
+	buf.Release()
+
+	if b := New(); b.Len() != 0 {
+		t.Fatalf("buffer length expected to be 0, got: %d", b.Len())
+	}
+}
+
+// BenchmarkBuffer_Oversized alternates large and small statements, the steady state
+// memory stays bounded as the large buffers are not retained by the pool.
+func BenchmarkBuffer_Oversized(b *testing.B) {
+	large := make([]byte, 1<<20)
+	small := make([]byte, 128)
+	b.ReportAllocs()
+
+	for x := 0; x < b.N; x++ {
+		buf := New()
+		if x%100 == 0 {
+			_, _ = buf.Write(large)
+		} else {
+			_, _ = buf.Write(small)
+		}
+		buf.Release()
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -18,6 +20,10 @@ var (
 
 // IsSlice return true if the given interface{} holds a slice type
 func IsSlice(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+
 	kind := reflect.TypeOf(v).Kind()
 	if reflect.TypeOf(v).Kind() == reflect.Ptr {
 		return reflect.Indirect(reflect.ValueOf(v)).Kind() == reflect.Slice
@@ -29,6 +35,9 @@ func IsSlice(v interface{}) bool {
 // Scan code adapted from https://github.com/mailru/dbr/blob/master/load.go
 
 // Load loads any value from sql.Rows
+// Slices are overwritten from the start, reusing their existing capacity
+// and truncated to the number of loaded rows. Non slice values are loaded from the
+// first row, returning sql.ErrNoRows if there are no rows.
 func Load(rows *sql.Rows, value interface{}) (int, error) {
 	defer rows.Close()
 	var count int
@@ -58,33 +67,197 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 		return count, err
 	}
 
+	// slices are loaded from the start reusing their existing capacity
+	if isSlice {
+		v.SetLen(0)
+	}
+
 	for rows.Next() {
 		var elem reflect.Value
 
 		if isSlice {
-			elem = reflect.New(elemType).Elem()
+			if count < v.Cap() {
+				v.SetLen(count + 1)
+				elem = v.Index(count)
+				elem.Set(reflect.Zero(elemType))
+			} else {
+				v.Set(reflect.Append(v, reflect.Zero(elemType)))
+				elem = v.Index(count)
+			}
 		} else {
 			elem = v
 		}
 
 		ptr := extractor(column, elem)
 
-		err = rows.Scan(ptr...)
+		err = ScanRow(rows, column, elemType, ptr)
 		if err != nil {
+			if isSlice {
+				v.SetLen(count)
+			}
 			return count, err
 		}
 		count++
 
-		if isSlice {
-			v.Set(reflect.Append(v, elem))
-		} else {
+		if !isSlice {
 			break
 		}
 	}
 
+	if err = rows.Err(); err != nil {
+		return count, err
+	}
+
+	// a non slice value must be loaded from a row
+	if !isSlice && count == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	return count, nil
+}
+
+// LoadMap loads rows into the map pointed by value, keyed by the given key column.
+// The remaining columns are loaded into the map values, which can be either a scalar,
+// requiring a single remaining column, or any other type supported by Load.
+func LoadMap(rows *sql.Rows, value interface{}, key string) (int, error) {
+	defer rows.Close()
+	var count int
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Map {
+		return 0, ErrInvalidType
+	}
+	v = v.Elem()
+
+	keyIdx := -1
+	valueColumns := make([]string, 0, len(columns))
+	for x := 0; x < len(columns); x++ {
+		if columns[x] == key {
+			keyIdx = x
+			continue
+		}
+		valueColumns = append(valueColumns, columns[x])
+	}
+
+	if keyIdx == -1 {
+		return 0, fmt.Errorf("statement: key column %s not found in columns: %v", key, columns)
+	}
+
+	keyType := v.Type().Key()
+	elemType := v.Type().Elem()
+
+	extractor, err := FindExtractor(elemType)
+	if err != nil {
+		return count, err
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	for rows.Next() {
+		k := reflect.New(keyType)
+		elem := reflect.New(elemType).Elem()
+
+		valuePtr := extractor(valueColumns, elem)
+		if len(valuePtr) != len(valueColumns) {
+			return count, fmt.Errorf("statement: invalid number of value columns for %v: %d", elemType, len(valueColumns))
+		}
+
+		ptr := make([]interface{}, 0, len(columns))
+		ptr = append(ptr, valuePtr[:keyIdx]...)
+		ptr = append(ptr, k.Interface())
+		ptr = append(ptr, valuePtr[keyIdx:]...)
+
+		if err = ScanRow(rows, columns, elemType, ptr); err != nil {
+			return count, err
+		}
+		count++
+
+		v.SetMapIndex(k.Elem(), elem)
+	}
+
 	return count, rows.Err()
 }
 
+// ScanError is returned when a column value can't be scanned into its destination,
+// identifying the column and the destination struct field, if any.
+type ScanError struct {
+	Column string
+	Field  string
+	Err    error
+}
+
+// Error returns the error message.
+func (e *ScanError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("statement: error scanning column %s: %s", e.Column, e.Err)
+	}
+	return fmt.Sprintf("statement: error scanning column %s into field %s: %s", e.Column, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying scan error.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// ScanRow scans the current row columns into the pointers extracted from a value of type t,
+// returning a *ScanError for the first column that can't be scanned.
+func ScanRow(rows *sql.Rows, columns []string, t reflect.Type, ptr []interface{}) (err error) {
+	if err = rows.Scan(ptr...); err == nil || len(ptr) != len(columns) {
+		return err
+	}
+
+	// find the failing column by scanning one column at a time
+	dest := make([]interface{}, len(ptr))
+	for x := 0; x < len(ptr); x++ {
+		for y := 0; y < len(dest); y++ {
+			dest[y] = dummyDest
+		}
+		dest[x] = ptr[x]
+
+		if rows.Scan(dest...) != nil {
+			return &ScanError{Column: columns[x], Field: fieldPath(t, columns[x]), Err: err}
+		}
+	}
+
+	return err
+}
+
+// fieldPath returns the path of the struct field mapped to the given column, e.g. `Contact.Address.City`,
+// or an empty string if t is not a struct or the column is not mapped.
+func fieldPath(t reflect.Type, column string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == typeTime || reflect.PtrTo(t).Implements(typeScanner) {
+		return ""
+	}
+
+	index, ok := StructMap(t)[column]
+	if !ok {
+		return ""
+	}
+
+	names := make([]string, 0, len(index))
+	for _, i := range index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		field := t.Field(i)
+		names = append(names, field.Name)
+		t = field.Type
+	}
+
+	return strings.Join(names, ".")
+}
+
 type dummyScanner struct{}
 
 func (dummyScanner) Scan(interface{}) error {
@@ -110,6 +283,69 @@ func (kv *kvScanner) Scan(v interface{}) error {
 	return nil
 }
 
+// epochScanner scans an integer Unix epoch in seconds into a time.Time or *time.Time field,
+// for fields tagged with the epoch option, e.g. `db:"created_at,epoch"`.
+type epochScanner struct {
+	dst reflect.Value
+}
+
+func (e *epochScanner) Scan(v interface{}) (err error) {
+	var sec int64
+	switch src := v.(type) {
+	case nil:
+		e.dst.Set(reflect.Zero(e.dst.Type()))
+		return nil
+	case int64:
+		sec = src
+	case []byte:
+		if sec, err = strconv.ParseInt(string(src), 10, 64); err != nil {
+			return err
+		}
+	case string:
+		if sec, err = strconv.ParseInt(src, 10, 64); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("statement: unsupported type %T for epoch time", v)
+	}
+
+	t := time.Unix(sec, 0)
+	if e.dst.Kind() == reflect.Ptr {
+		e.dst.Set(reflect.ValueOf(&t))
+		return nil
+	}
+
+	e.dst.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// epochColumns returns the columns mapped to time.Time or *time.Time fields tagged with the epoch option.
+func epochColumns(t reflect.Type, mapping map[string][]int) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var epoch map[string]bool
+	for column, index := range mapping {
+		field := t.FieldByIndex(index)
+		if field.Type != typeTime && field.Type != reflect.PtrTo(typeTime) {
+			continue
+		}
+
+		_, opts, _ := strings.Cut(field.Tag.Get("db"), ",")
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "epoch" {
+				if epoch == nil {
+					epoch = make(map[string]bool)
+				}
+				epoch[column] = true
+			}
+		}
+	}
+
+	return epoch
+}
+
 // PointersExtractor function type
 type PointersExtractor func(columns []string, value reflect.Value) []interface{}
 
@@ -117,14 +353,20 @@ var (
 	dummyDest       sql.Scanner = dummyScanner{}
 	typeScanner                 = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
 	typeKeyValueMap             = reflect.TypeOf(keyValueMap(nil))
+	typeTime                    = reflect.TypeOf(time.Time{})
 )
 
 func getStructFieldsExtractor(t reflect.Type) PointersExtractor {
 	mapping := StructMap(t)
+	epoch := epochColumns(t, mapping)
 	return func(columns []string, value reflect.Value) []interface{} {
 		var ptr []interface{}
 		for _, key := range columns {
 			if index, ok := mapping[key]; ok {
+				if epoch[key] {
+					ptr = append(ptr, &epochScanner{dst: value.FieldByIndex(index)})
+					continue
+				}
 				ptr = append(ptr, value.FieldByIndex(index).Addr().Interface())
 			} else {
 				ptr = append(ptr, dummyDest)
@@ -159,9 +401,10 @@ func dummyExtractor(columns []string, value reflect.Value) []interface{} {
 	return []interface{}{value.Addr().Interface()}
 }
 
-// FindExtractor returns a PointersExtractor for the given type
+// FindExtractor returns a PointersExtractor for the given type.
+// Scalar types, including time.Time and sql.Scanner implementations, are scanned from a single column.
 func FindExtractor(t reflect.Type) (PointersExtractor, error) {
-	if reflect.PtrTo(t).Implements(typeScanner) {
+	if reflect.PtrTo(t).Implements(typeScanner) || t == typeTime {
 		return dummyExtractor, nil
 	}
 
@@ -191,35 +434,88 @@ func StructMap(t reflect.Type) map[string][]int {
 	}
 
 	m := make(map[string][]int)
-	structTraverse(m, t, nil)
+	structTraverse(m, nil, t, nil)
 	return m
 }
 
-func structTraverse(m map[string][]int, t reflect.Type, head []int) {
+// StructMapOrdered builds the same index as StructMap and also returns the column names
+// in struct field declaration order. Nested and embedded structs are flattened, so only the columns
+// of their fields are returned, in place of the parent field.
+func StructMapOrdered(t reflect.Type) (map[string][]int, []string) {
+	m := make(map[string][]int)
+	var columns []string
+	structTraverse(m, &columns, t, nil)
+	return m, columns
+}
+
+// Columns returns the column names the given struct value or reflect.Type maps to in field declaration order,
+// honoring db tags, as used by Load and the statement Record methods. Nested struct fields are represented
+// by the columns of their own fields.
+// It returns nil if the given value is not a struct.
+func Columns(v interface{}) []string {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct || t == typeTime {
+		return nil
+	}
+
+	_, columns := StructMapOrdered(t)
+	return columns
+}
+
+// isNestedStruct reports whether t is a struct mapped through its own fields,
+// rather than scanned from a single column.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Implements(typeValuer) {
+		return false
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t != typeTime &&
+		!t.Implements(typeValuer) && !reflect.PtrTo(t).Implements(typeScanner)
+}
+
+func structTraverse(m map[string][]int, columns *[]string, t reflect.Type, head []int) {
 	if t.Implements(typeValuer) {
 		return
 	}
 	switch t.Kind() {
 	case reflect.Ptr:
-		structTraverse(m, t.Elem(), head)
+		structTraverse(m, columns, t.Elem(), head)
 	case reflect.Struct:
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
 			if field.PkgPath != "" && !field.Anonymous {
 				continue // not exported
 			}
-			tag := field.Tag.Get("db")
+			tag, _, _ := strings.Cut(field.Tag.Get("db"), ",")
 			if tag == "-" {
-				continue // ignore
+				continue // ignore, including any nested fields
 			}
 			if tag == "" {
 				// no tag, but we can record the field name
 				tag = camelCaseToSnakeCase(field.Name)
 			}
+			// full slice expression so sibling fields never share the same backing array
+			index := append(head[:len(head):len(head)], i)
 			if _, ok := m[tag]; !ok {
-				m[tag] = append(head, i)
+				m[tag] = index
+				// nested and embedded structs are flattened into the columns of their own fields
+				if columns != nil && !isNestedStruct(field.Type) {
+					*columns = append(*columns, tag)
+				}
 			}
-			structTraverse(m, field.Type, append(head, i))
+			structTraverse(m, columns, field.Type, index)
 		}
 	}
 }
@@ -0,0 +1,312 @@
+package scan
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func queryRows(t testing.TB, rows *sqlmock.Rows) (r *sql.Rows, closeFn func()) {
+	t.Helper()
+
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	r, err = mdb.Query("SELECT")
+	if err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	return r, func() { _ = mdb.Close() }
+}
+
+func TestLoadScalarSlice(t *testing.T) {
+	r, closeFn := queryRows(t, sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	defer closeFn()
+
+	var ids []int
+	n, err := Load(r, &ids)
+	if err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if expected := []int{1, 2, 3}; n != 3 || !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected: %v, got: %v, count: %d", expected, ids, n)
+	}
+
+	r, closeFn = queryRows(t, sqlmock.NewRows([]string{"name"}).AddRow("john").AddRow("jane"))
+	defer closeFn()
+
+	var names []string
+	if n, err = Load(r, &names); err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if expected := []string{"john", "jane"}; n != 2 || !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected: %v, got: %v, count: %d", expected, names, n)
+	}
+}
+
+func TestLoadTimeSlice(t *testing.T) {
+	now := time.Now()
+	r, closeFn := queryRows(t, sqlmock.NewRows([]string{"created_at"}).AddRow(now).AddRow(now.Add(time.Hour)))
+	defer closeFn()
+
+	var dates []time.Time
+	n, err := Load(r, &dates)
+	if err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if n != 2 || !dates[0].Equal(now) || !dates[1].Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected dates: %v, count: %d", dates, n)
+	}
+}
+
+func TestStructMapSkipped(t *testing.T) {
+	type audit struct {
+		CreatedBy string
+		UpdatedBy string
+	}
+
+	type address struct {
+		City   string
+		Street string
+	}
+
+	type contact struct {
+		Email   string
+		Address address
+	}
+
+	type user struct {
+		ID      int
+		audit   `db:"-"`
+		Contact contact
+		Secret  audit `db:"-"`
+	}
+
+	m := StructMap(reflect.TypeOf(user{}))
+
+	for _, column := range []string{"audit", "secret", "created_by", "updated_by"} {
+		if _, ok := m[column]; ok {
+			t.Fatalf("unexpected column %s in mapping: %v", column, m)
+		}
+	}
+
+	expected := map[string][]int{
+		"id":      {0},
+		"contact": {2},
+		"email":   {2, 0},
+		"address": {2, 1},
+		"city":    {2, 1, 0},
+		"street":  {2, 1, 1},
+	}
+
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, m)
+	}
+}
+
+func TestStructMapOrdered(t *testing.T) {
+	type Audit struct {
+		CreatedBy string
+		UpdatedBy string
+	}
+
+	type user struct {
+		Name string
+		ID   int `db:"user_id"`
+		Audit
+		Email string
+		Base  Audit
+		Role  string
+	}
+
+	m, columns := StructMapOrdered(reflect.TypeOf(user{}))
+
+	// fields already mapped by the embedded Audit are not repeated for Base
+	expected := []string{"name", "user_id", "created_by", "updated_by", "email", "role"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, columns)
+	}
+
+	if !reflect.DeepEqual(m, StructMap(reflect.TypeOf(user{}))) {
+		t.Fatalf("expected the same mapping as StructMap, got: %v", m)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	type Audit struct {
+		CreatedBy string
+		UpdatedAt time.Time
+	}
+
+	type address struct {
+		City   string
+		Street string `db:"street_name"`
+	}
+
+	type user struct {
+		ID int64 `db:"user_id"`
+		Audit
+		Name     string
+		Password string `db:"-"`
+		Email    sql.NullString
+		Address  *address
+		Epoch    time.Time `db:"created_at,epoch"`
+	}
+
+	expected := []string{"user_id", "created_by", "updated_at", "name", "email", "city", "street_name", "created_at"}
+
+	if columns := Columns(&user{}); !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, columns)
+	}
+
+	if columns := Columns(reflect.TypeOf(user{})); !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, columns)
+	}
+
+	if columns := Columns(time.Time{}); columns != nil {
+		t.Fatalf("expected no columns for a non struct, got: %v", columns)
+	}
+}
+
+func TestLoadScanError(t *testing.T) {
+	type address struct {
+		ZipCode int
+	}
+
+	type user struct {
+		ID      int
+		Name    string
+		Address address
+	}
+
+	r, closeFn := queryRows(t, sqlmock.NewRows([]string{"id", "name", "zip_code"}).AddRow(1, "john", "not a number"))
+	defer closeFn()
+
+	var users []user
+	_, err := Load(r, &users)
+
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("expected *ScanError, got: %v", err)
+	}
+
+	if scanErr.Column != "zip_code" || scanErr.Field != "Address.ZipCode" {
+		t.Fatalf("expected column: zip_code, field: Address.ZipCode, got column: %s, field: %s", scanErr.Column, scanErr.Field)
+	}
+
+	if !strings.Contains(err.Error(), "zip_code") {
+		t.Fatalf("expected error to name the column, got: %s", err)
+	}
+
+	// scalar destinations name only the column
+	r, closeFn = queryRows(t, sqlmock.NewRows([]string{"id"}).AddRow("abc"))
+	defer closeFn()
+
+	var ids []int
+	if _, err = Load(r, &ids); !errors.As(err, &scanErr) || scanErr.Column != "id" || scanErr.Field != "" {
+		t.Fatalf("expected *ScanError for column id, got: %v", err)
+	}
+}
+
+func TestLoadEpochTime(t *testing.T) {
+	type event struct {
+		ID        int
+		CreatedAt time.Time  `db:"created_at,epoch"`
+		DeletedAt *time.Time `db:"deleted_at,epoch"`
+	}
+
+	r, closeFn := queryRows(t, sqlmock.NewRows([]string{"id", "created_at", "deleted_at"}).
+		AddRow(1, 1600000000, 1700000000).
+		AddRow(2, int64(1600000060), nil))
+	defer closeFn()
+
+	var events []event
+	n, err := Load(r, &events)
+	if err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if n != 2 || !events[0].CreatedAt.Equal(time.Unix(1600000000, 0)) || !events[1].CreatedAt.Equal(time.Unix(1600000060, 0)) {
+		t.Fatalf("unexpected created_at times: %v, count: %d", events, n)
+	}
+
+	if events[0].DeletedAt == nil || !events[0].DeletedAt.Equal(time.Unix(1700000000, 0)) || events[1].DeletedAt != nil {
+		t.Fatalf("unexpected deleted_at times: %v, %v", events[0].DeletedAt, events[1].DeletedAt)
+	}
+
+	// the column name is taken from the tag without its options
+	if _, ok := StructMap(reflect.TypeOf(event{}))["created_at"]; !ok {
+		t.Fatalf("expected created_at column in mapping")
+	}
+}
+
+func TestLoadSliceReuse(t *testing.T) {
+	ids := make([]int, 5, 10)
+	for x := 0; x < len(ids); x++ {
+		ids[x] = 100 + x
+	}
+	backing := &ids[:cap(ids)][0]
+
+	r, closeFn := queryRows(t, sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	defer closeFn()
+
+	n, err := Load(r, &ids)
+	if err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if expected := []int{1, 2, 3}; n != 3 || !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected: %v, got: %v, count: %d", expected, ids, n)
+	}
+
+	if &ids[:cap(ids)][0] != backing || cap(ids) != 10 {
+		t.Fatalf("expected the slice backing array to be reused")
+	}
+}
+
+func BenchmarkLoadSliceReuse(b *testing.B) {
+	type user struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	rows := func() *sqlmock.Rows {
+		r := sqlmock.NewRows([]string{"id", "name", "email"})
+		for x := 0; x < 100; x++ {
+			r.AddRow(x, "john.doe", "john.doe@email.com")
+		}
+		return r
+	}
+
+	users := make([]user, 0, 100)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for x := 0; x < b.N; x++ {
+		b.StopTimer()
+		r, closeFn := queryRows(b, rows())
+		b.StartTimer()
+
+		if _, err := Load(r, &users); err != nil {
+			b.Fatalf("error loading rows: %s", err)
+		}
+
+		b.StopTimer()
+		closeFn()
+		b.StartTimer()
+	}
+}
@@ -19,12 +19,29 @@ var (
 	// StdLog is the log.Printf function from the standard library
 	StdLog = log.Printf
 
+	// ErrUnmatchedFile will be returned in strict mode when a file does not match the migration file pattern
+	ErrUnmatchedFile = fmt.Errorf("migrate: file does not match the migration file pattern")
+
+	// ErrDuplicateVersion will be returned when a migration version is defined more than once
+	ErrDuplicateVersion = fmt.Errorf("migrate: duplicate migration version")
+
+	// ErrMissingDirection will be returned in strict mode when a migration version lacks its apply or discard file
+	ErrMissingDirection = fmt.Errorf("migrate: migration missing apply or discard file")
+
+	// ErrNonAtomicMigration will be returned in strict mode when a transactional migration combines DDL with
+	// other statements for a dialect where DDL statements implicitly commit the transaction
+	ErrNonAtomicMigration = fmt.Errorf("migrate: ddl statements implicitly commit and the migration is not atomic")
+
+	// ddlRegexp matches statements starting with a DDL verb
+	ddlRegexp = regexp.MustCompile(`(?i)^\s*(CREATE|ALTER|DROP|TRUNCATE|RENAME)\s`)
+
 	// 0001_initial_schema.apply.sql
 	// 0001_initial_schema.discard.sql
 	migrationRegexp = regexp.MustCompile(`(\d+)_(\w+)\.(apply|discard)\.sql`)
 	options         = &sql.TxOptions{Isolation: sql.LevelSerializable}
 
-	versionQuery = "SELECT version, date, name FROM migrations ORDER BY date DESC LIMIT 1"
+	versionQuery        = "SELECT version, date, name FROM migrations ORDER BY date DESC LIMIT 1"
+	appliedVersionQuery = "SELECT version, date, name FROM migrations WHERE version = (SELECT MAX(version) FROM migrations WHERE applied)"
 
 	migration0 = &Migration{
 		Version: 0,
@@ -39,6 +56,18 @@ var (
 			Statements: []string{`DROP TABLE IF EXISTS migrations CASCADE`},
 		},
 	}
+
+	// migration0Applied creates the migrations table for the WithAppliedFlag scheme
+	migration0Applied = &Migration{
+		Version: 0,
+		Name:    "create_migrations_table",
+		Apply: Statements{
+			NoTx: false,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS migrations (version bigint NOT NULL, date timestamp NOT NULL, name varchar(512) NOT NULL, applied boolean NOT NULL, PRIMARY KEY (version))`},
+		},
+		Discard: migration0.Discard,
+	}
 )
 
 // Executor executes statements in a database
@@ -52,11 +81,103 @@ type Logger func(s string, args ...interface{})
 // nopLogger does notting
 func nopLogger(_ string, _ ...interface{}) {}
 
+// Migration event phases
+const (
+	// PhaseApply is the phase of the statements and function applying a migration
+	PhaseApply = "apply"
+
+	// PhaseDiscard is the phase of the statements and function discarding a migration
+	PhaseDiscard = "discard"
+
+	// PhaseVersion is the phase of the statement recording the migration version
+	PhaseVersion = "version"
+)
+
+// EventLogger receives structured migration events, allowing machine parseable migration logs.
+// It is invoked after each statement is executed with the migration version and name, the phase,
+// the statement and its error, if any. The statement is empty for migration functions.
+type EventLogger func(version int64, name, phase, stmt string, err error)
+
+// nopEventLogger does nothing
+func nopEventLogger(_ int64, _, _, _ string, _ error) {}
+
+// Option configures optional Migrate behavior
+type Option func(c *config)
+
+// config holds the optional Migrate configuration
+type config struct {
+	pattern *regexp.Regexp
+	strict  bool
+	applied bool
+	events  EventLogger
+	dialect *statement.Dialect
+}
+
+// WithPattern sets the regular expression used by NewWithFiles to match migration files.
+// The pattern must have exactly 3 capture groups, matching respectively the migration
+// version, name and direction (`apply` or `discard`).
+// The default pattern is `(\d+)_(\w+)\.(apply|discard)\.sql`.
+func WithPattern(pattern *regexp.Regexp) Option {
+	return func(c *config) {
+		c.pattern = pattern
+	}
+}
+
+// WithStrict makes NewWithFiles return an error when a file does not match the migration pattern,
+// instead of only logging it, or when a migration version is missing its apply or discard file.
+// It also makes non atomic migrations detected with WithDialect an error instead of a warning.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithAppliedFlag keeps a single row per migration version in the migrations table with an applied flag,
+// instead of recording a new row for every version change. The current version is the greatest applied version,
+// so the table doesn't grow on repeated apply and discard cycles and doesn't depend on the database clock.
+//
+// The migrations table schemes are not compatible, so this option must be used from the first migration.
+func WithAppliedFlag() Option {
+	return func(c *config) {
+		c.applied = true
+	}
+}
+
+// WithEventLogger sets an EventLogger receiving structured events for the migration statements and functions
+// run by Migrate, alongside the Logger.
+func WithEventLogger(events EventLogger) Option {
+	return func(c *config) {
+		c.events = events
+	}
+}
+
+// WithDialect sets the database dialect, which is used to build the version bookkeeping statements
+// and to check that transactional migrations are atomic.
+// For dialects where DDL statements implicitly commit the transaction, like MySQL, migrations combining
+// DDL with other statements are logged as warnings, or returned as ErrNonAtomicMigration with WithStrict.
+func WithDialect(d *statement.Dialect) Option {
+	return func(c *config) {
+		c.dialect = d
+	}
+}
+
+func newConfig(opts []Option) (c *config) {
+	c = &config{pattern: migrationRegexp}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
 // Migrate manages database migrations
 type Migrate struct {
-	db         *sql.DB
-	logger     func(s string, args ...interface{})
-	migrations []*Migration
+	db           *sql.DB
+	logger       func(s string, args ...interface{})
+	events       EventLogger
+	migrations   []*Migration
+	applied      bool
+	versionQuery string
+	builder      *statement.Builder
 }
 
 // Migration represents a database migration apply and discard statements
@@ -67,10 +188,15 @@ type Migration struct {
 	Discard Statements
 }
 
-// Statements are set of SQL statements that either apply or discard a migration
+// Statements are set of SQL statements that either apply or discard a migration.
+//
+// Func is an optional function for changes not expressible in SQL, like data transformations.
+// It is run after the SQL statements with an Executor for the migration transaction, or for
+// the database if NoTx is set.
 type Statements struct {
 	NoTx       bool
 	Statements []string
+	Func       func(ctx context.Context, exec Executor) error
 }
 
 // Version represents a migration version and its metadata
@@ -84,19 +210,34 @@ type Version struct {
 //
 // If the provided logger function is not `nil` additional information will be logged during the
 // migrations apply or discard.
-func New(db *sql.DB, logger Logger, migrations []*Migration) (m *Migrate, err error) {
+func New(db *sql.DB, logger Logger, migrations []*Migration, opts ...Option) (m *Migrate, err error) {
 	if len(migrations) == 0 {
 		return nil, fmt.Errorf("migrate: no migrations where provided")
 	}
+	c := newConfig(opts)
+
 	m = &Migrate{}
 	m.db = db
+	m.applied = c.applied
+	m.builder = statement.NewBuilder(c.dialect)
+	m.versionQuery = versionQuery
 	m.migrations = append(m.migrations, migration0)
 
+	if m.applied {
+		m.versionQuery = appliedVersionQuery
+		m.migrations[0] = migration0Applied
+	}
+
 	if logger == nil {
 		logger = nopLogger
 	}
 	m.logger = logger
 
+	m.events = c.events
+	if m.events == nil {
+		m.events = nopEventLogger
+	}
+
 	for _, mig := range migrations {
 		if mig.Version <= 0 {
 			return nil, fmt.Errorf("migrate: migration version must be greater than 0")
@@ -116,21 +257,84 @@ func New(db *sql.DB, logger Logger, migrations []*Migration) (m *Migrate, err er
 		}
 	}
 
+	if c.dialect != nil && c.dialect.DDLAutoCommit {
+		for _, mig := range m.migrations {
+			for _, discard := range []bool{false, true} {
+				statements := mig.Apply
+				if discard {
+					statements = mig.Discard
+				}
+
+				if atomic(statements) {
+					continue
+				}
+
+				if c.strict {
+					return nil, fmt.Errorf("%w: %s, discard: %t, dialect: %s", ErrNonAtomicMigration, mig.Name, discard, c.dialect.Name)
+				}
+				m.logger("migrate: warning: %s, discard: %t, ddl statements implicitly commit with the %s dialect, the migration is not atomic",
+					mig.Name, discard, c.dialect.Name)
+			}
+		}
+	}
+
 	return m, nil
 }
 
+// atomic reports whether the given statements are atomic when DDL statements implicitly commit the transaction,
+// which requires transactional statements not to combine DDL with other statements or functions.
+func atomic(s Statements) bool {
+	if s.NoTx {
+		return true
+	}
+
+	ddl := false
+	for x := 0; x < len(s.Statements); x++ {
+		ddl = ddl || ddlRegexp.MatchString(s.Statements[x])
+	}
+
+	return !ddl || (len(s.Statements) == 1 && s.Func == nil)
+}
+
 // NewWithFiles is like new but takes a fs.Fs as a source for migration files.
-// Only files within the 1st level of the provided path matching the `(\d+)_(\w+)\.(apply|discard)\.sql`
-// pattern will be added to the Migrate catalog.
-func NewWithFiles(db *sql.DB, logger Logger, files fs.FS) (m *Migrate, err error) {
+// Files within the provided fs.FS and all of its subdirectories whose names match the
+// `(\d+)_(\w+)\.(apply|discard)\.sql` pattern will be added to the Migrate catalog.
+// The pattern can be changed with the WithPattern option.
+//
+// Migrations can be organized in nested directories, but each version and direction
+// must be unique across the whole tree, and all files for a version must share the same name.
+//
+// Files not matching the pattern are logged as skipped, or returned as an error when
+// the WithStrict option is provided, which also requires both apply and discard files for every version.
+func NewWithFiles(db *sql.DB, logger Logger, files fs.FS, opts ...Option) (m *Migrate, err error) {
 	if logger == nil {
 		logger = nopLogger
 	}
 
+	migrations, err := loadMigrations(files, logger, newConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return New(db, logger, migrations, opts...)
+}
+
+// LoadMigrations parses the migration files from the given fs.FS as in NewWithFiles and returns the
+// migrations sorted by version without requiring a database, so they can be inspected or provided to New.
+func LoadMigrations(files fs.FS, opts ...Option) (migrations []*Migration, err error) {
+	return loadMigrations(files, nopLogger, newConfig(opts))
+}
+
+func loadMigrations(files fs.FS, logger Logger, c *config) (arg []*Migration, err error) {
+	if c.pattern.NumSubexp() != 3 {
+		return nil, fmt.Errorf("migrate: migration file pattern must have 3 capture groups, got: %d", c.pattern.NumSubexp())
+	}
+
 	migrations := make(map[int64]*Migration)
+	sources := make(map[string]string) // version.direction / path
 
-	// walk the provided fs.FS matching found 1st level files matching with the migrationRegexp
-	// and adding them to the Migrate catalog
+	// walk the provided fs.FS and its subdirectories matching found files with the
+	// migration pattern and adding them to the Migrate catalog
 	err = fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -141,9 +345,12 @@ func NewWithFiles(db *sql.DB, logger Logger, files fs.FS) (m *Migrate, err error
 			return nil
 		}
 
-		match := migrationRegexp.FindStringSubmatch(d.Name())
+		match := c.pattern.FindStringSubmatch(d.Name())
 		if len(match) != 4 {
-			logger("migrate: could not match file in provided versions: %s, data: %#v", d.Name(), match)
+			if c.strict {
+				return fmt.Errorf("%w: %s", ErrUnmatchedFile, path)
+			}
+			logger("migrate: skipping file not matching the migration pattern: %s", path)
 			return nil
 		}
 
@@ -156,12 +363,22 @@ func NewWithFiles(db *sql.DB, logger Logger, files fs.FS) (m *Migrate, err error
 			return fmt.Errorf("migrate: migration version must be greater than 0")
 		}
 
+		key := fmt.Sprintf("%d.%s", version, match[3])
+		if source, ok := sources[key]; ok {
+			return fmt.Errorf("%w: %d, files: %s, %s", ErrDuplicateVersion, version, source, path)
+		}
+		sources[key] = path
+
 		mig, ok := migrations[version]
 		if !ok {
 			mig = &Migration{Version: version, Name: match[2]}
 			migrations[version] = mig
 		}
-		logger("migrate: adding entry for: %s, file: %s", match[2], d.Name())
+
+		if mig.Name != match[2] {
+			return fmt.Errorf("%w: %d, names: %s, %s", ErrDuplicateVersion, version, mig.Name, match[2])
+		}
+		logger("migrate: adding entry for: %s, file: %s", match[2], path)
 
 		source, err := fs.ReadFile(files, path)
 		if err != nil {
@@ -173,6 +390,8 @@ func NewWithFiles(db *sql.DB, logger Logger, files fs.FS) (m *Migrate, err error
 			mig.Apply, err = parseStatement(source)
 		case "discard":
 			mig.Discard, err = parseStatement(source)
+		default:
+			err = fmt.Errorf("migrate: invalid migration direction: %s, file: %s", match[3], path)
 		}
 
 		return err
@@ -182,12 +401,31 @@ func NewWithFiles(db *sql.DB, logger Logger, files fs.FS) (m *Migrate, err error
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
-	var arg []*Migration
 	for _, m := range migrations {
 		arg = append(arg, m)
 	}
 
-	return New(db, logger, arg)
+	sort.Slice(arg, func(i, j int) bool {
+		return arg[i].Version < arg[j].Version
+	})
+
+	// in strict mode every version must provide both directions
+	if c.strict {
+		var missing []string
+		for _, m := range arg {
+			for _, direction := range []string{"apply", "discard"} {
+				if _, ok := sources[fmt.Sprintf("%d.%s", m.Version, direction)]; !ok {
+					missing = append(missing, fmt.Sprintf("%d_%s.%s", m.Version, m.Name, direction))
+				}
+			}
+		}
+
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMissingDirection, strings.Join(missing, ", "))
+		}
+	}
+
+	return arg, nil
 }
 
 // Versions return the list of migration versions available to this migrate instance.
@@ -214,7 +452,7 @@ func (m *Migrate) Version(ctx context.Context) (version *Version, err error) {
 }
 
 func (m *Migrate) version(ctx context.Context, tx *sql.Tx) (version *Version, err error) {
-	row := tx.QueryRowContext(ctx, versionQuery)
+	row := tx.QueryRowContext(ctx, m.versionQuery)
 
 	version = &Version{}
 	err = row.Scan(&version.Version, &version.Date, &version.Name)
@@ -229,20 +467,43 @@ func (m *Migrate) version(ctx context.Context, tx *sql.Tx) (version *Version, er
 	return version, nil
 }
 
-// Up apply all existing migrations to the database
-func (m *Migrate) Up(ctx context.Context) (err error) {
+// Up apply all existing migrations to the database, returning the applied versions in order.
+func (m *Migrate) Up(ctx context.Context) (versions []int64, err error) {
 	return m.Apply(ctx, m.migrations[len(m.migrations)-1].Version)
 }
 
-// Down discards all existing database migrations and migration history
-func (m *Migrate) Down(ctx context.Context) (err error) {
+// Down discards all existing database migrations and migration history,
+// returning the discarded versions in order.
+func (m *Migrate) Down(ctx context.Context) (versions []int64, err error) {
 	return m.Apply(ctx, -1)
 }
 
-func (m *Migrate) set(ctx context.Context, tx *sql.Tx, mig *Migration) (err error) {
-	stmt, err := statement.Insert().Into("migrations").
-		Columns("version", "date", "name").
-		Values(mig.Version, statement.Ident("NOW()"), mig.Name).String()
+// set records the given migration as applied or discarded.
+func (m *Migrate) set(ctx context.Context, tx *sql.Tx, mig *Migration, discard bool) (err error) {
+	var stmt string
+
+	switch {
+	case m.applied && discard:
+		stmt, err = m.builder.Update().Table("migrations").
+			Set("applied", false).Set("date", statement.Ident("NOW()")).
+			Where("version = ?", mig.Version).String()
+
+	case m.applied:
+		stmt, err = m.builder.Insert().Into("migrations").
+			Columns("version", "date", "name", "applied").
+			Values(mig.Version, statement.Ident("NOW()"), mig.Name, true).
+			UpsertOnConflict("version").String()
+
+	default:
+		// the current version is the last recorded one
+		if discard {
+			mig = m.migrations[mig.Version-1]
+		}
+
+		stmt, err = m.builder.Insert().Into("migrations").
+			Columns("version", "date", "name").
+			Values(mig.Version, statement.Ident("NOW()"), mig.Name).String()
+	}
 
 	if err != nil {
 		return err
@@ -250,18 +511,21 @@ func (m *Migrate) set(ctx context.Context, tx *sql.Tx, mig *Migration) (err erro
 
 	m.logger(`migrate: update version, statement: %s`, stmt)
 	_, err = tx.ExecContext(ctx, stmt)
+	m.events(mig.Version, mig.Name, PhaseVersion, stmt, err)
 	return err
 }
 
-// Apply either rolls forward or backwards the migrations to the specified version
-func (m *Migrate) Apply(ctx context.Context, version int64) (err error) {
-	if len(m.migrations) < int(version) && version != -1 {
-		return fmt.Errorf("migrate: specified version: %d does not exist", version)
+// Apply either rolls forward or backwards the migrations to the specified version,
+// returning the applied or discarded versions in the order they were run.
+// On error the versions successfully run before the failure are returned.
+func (m *Migrate) Apply(ctx context.Context, version int64) (versions []int64, err error) {
+	if version >= int64(len(m.migrations)) || version < -1 {
+		return nil, fmt.Errorf("migrate: specified version: %d does not exist", version)
 	}
 
 	current, err := m.Version(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var migrations []*Migration
@@ -269,26 +533,28 @@ func (m *Migrate) Apply(ctx context.Context, version int64) (err error) {
 	case current.Version < version:
 		migrations = m.migrations[current.Version+1 : version+1]
 
-		for mig := range migrations {
-			if err := m.apply(ctx, m.migrations[mig], false); err != nil {
-				return err
+		for x := 0; x < len(migrations); x++ {
+			if err := m.apply(ctx, migrations[x], false); err != nil {
+				return versions, err
 			}
+			versions = append(versions, migrations[x].Version)
 		}
 
 	case current.Version > version:
 		migrations = m.migrations[version+1 : current.Version+1]
 
 		for x := len(migrations) - 1; x >= 0; x-- {
-			if err := m.apply(ctx, m.migrations[x], true); err != nil {
-				return err
+			if err := m.apply(ctx, migrations[x], true); err != nil {
+				return versions, err
 			}
+			versions = append(versions, migrations[x].Version)
 		}
 
 	case current.Version == version:
-		return nil
+		return nil, nil
 	}
 
-	return nil
+	return versions, nil
 }
 
 func (m *Migrate) apply(ctx context.Context, mig *Migration, discard bool) (err error) {
@@ -297,6 +563,13 @@ func (m *Migrate) apply(ctx context.Context, mig *Migration, discard bool) (err
 		return err
 	}
 
+	// ensure the migration and version bookkeeping are discarded on failure
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
 	current, err := m.version(ctx, tx)
 	if err != nil {
 		return err
@@ -311,6 +584,11 @@ func (m *Migrate) apply(ctx context.Context, mig *Migration, discard bool) (err
 		}
 	}
 
+	phase := PhaseApply
+	if discard {
+		phase = PhaseDiscard
+	}
+
 	var statements Statements
 	switch discard {
 	case false:
@@ -332,18 +610,43 @@ func (m *Migrate) apply(ctx context.Context, mig *Migration, discard bool) (err
 	}
 
 	for x := 0; x < len(statements.Statements); x++ {
+		// stop promptly between statements if the context was cancelled
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
 		m.logger("migrate: %s, discard: %t, transaction: %t, statement: %s", mig.Name, discard, !statements.NoTx, statements.Statements[x])
 
 		switch statements.NoTx {
 		case false:
-			if _, err := tx.ExecContext(ctx, statements.Statements[x]); err != nil {
-				return err
-			}
-
+			_, err = tx.ExecContext(ctx, statements.Statements[x])
 		case true:
-			if _, err := m.db.ExecContext(ctx, statements.Statements[x]); err != nil {
-				return err
-			}
+			_, err = m.db.ExecContext(ctx, statements.Statements[x])
+		}
+		m.events(mig.Version, mig.Name, phase, statements.Statements[x], err)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if statements.Func != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		m.logger("migrate: %s, discard: %t, transaction: %t, running migration function", mig.Name, discard, !statements.NoTx)
+
+		var exec Executor = tx
+		if statements.NoTx {
+			exec = m.db
+		}
+
+		err = statements.Func(ctx, exec)
+		m.events(mig.Version, mig.Name, phase, "", err)
+
+		if err != nil {
+			return err
 		}
 	}
 
@@ -353,11 +656,7 @@ func (m *Migrate) apply(ctx context.Context, mig *Migration, discard bool) (err
 	}
 
 	// set the current version after applying the migration
-	if discard {
-		mig = m.migrations[mig.Version-1]
-	}
-
-	if err = m.set(ctx, tx, mig); err != nil {
+	if err = m.set(ctx, tx, mig, discard); err != nil {
 		return err
 	}
 
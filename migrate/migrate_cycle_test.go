@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/brunotm/norm/statement"
+)
+
+func TestMigrationCycle(t *testing.T) {
+	cases := []struct {
+		name         string
+		opts         []Option
+		versionQuery string
+		migration0   *Migration
+		set0         string
+		set1         string
+		unset1       string
+	}{
+		{
+			name:         "history",
+			versionQuery: versionQuery,
+			migration0:   migration0,
+			set0:         `INSERT INTO migrations(version,date,name) VALUES (0,NOW(),'create_migrations_table')`,
+			set1:         `INSERT INTO migrations(version,date,name) VALUES (1,NOW(),'users_table')`,
+			unset1:       `INSERT INTO migrations(version,date,name) VALUES (0,NOW(),'create_migrations_table')`,
+		},
+		{
+			name:         "applied_flag",
+			opts:         []Option{WithAppliedFlag()},
+			versionQuery: appliedVersionQuery,
+			migration0:   migration0Applied,
+			set0:         `INSERT INTO migrations(version,date,name,applied) VALUES (0,NOW(),'create_migrations_table',true) ON CONFLICT (version) DO UPDATE SET date = EXCLUDED.date, name = EXCLUDED.name, applied = EXCLUDED.applied`,
+			set1:         `INSERT INTO migrations(version,date,name,applied) VALUES (1,NOW(),'users_table',true) ON CONFLICT (version) DO UPDATE SET date = EXCLUDED.date, name = EXCLUDED.name, applied = EXCLUDED.applied`,
+			unset1:       `UPDATE migrations SET applied = false, date = NOW() WHERE version = 1`,
+		},
+		{
+			name:         "applied_flag_mysql",
+			opts:         []Option{WithAppliedFlag(), WithDialect(statement.MySQLDialect)},
+			versionQuery: appliedVersionQuery,
+			migration0:   migration0Applied,
+			set0:         `INSERT INTO migrations(version,date,name,applied) VALUES (0,NOW(),'create_migrations_table',1) ON DUPLICATE KEY UPDATE date = VALUES(date), name = VALUES(name), applied = VALUES(applied)`,
+			set1:         `INSERT INTO migrations(version,date,name,applied) VALUES (1,NOW(),'users_table',1) ON DUPLICATE KEY UPDATE date = VALUES(date), name = VALUES(name), applied = VALUES(applied)`,
+			unset1:       `UPDATE migrations SET applied = 0, date = NOW() WHERE version = 1`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer mdb.Close()
+
+			rows := func(mig *Migration) *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"version", "date", "name"}).AddRow(mig.Version, time.Now(), mig.Name)
+			}
+
+			// up: migrations table doesn't exist
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnError(fmt.Errorf("relation does not exist"))
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnError(fmt.Errorf("relation does not exist"))
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectExec(tt.migration0.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(tt.set0).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnRows(rows(tt.migration0))
+			mock.ExpectExec(migration1.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(tt.set1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			// down to version 0
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnRows(rows(migration1))
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnRows(rows(migration1))
+			mock.ExpectExec(migration1.Discard.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(tt.unset1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			// up again
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnRows(rows(tt.migration0))
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.versionQuery).WillReturnRows(rows(tt.migration0))
+			mock.ExpectExec(migration1.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(tt.set1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			m, err := New(mdb, StdLog, []*Migration{migration1}, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create migrate: %s", err)
+			}
+
+			ctx := context.Background()
+
+			versions, err := m.Up(ctx)
+			if err != nil {
+				t.Fatalf("migration up failed: %s", err)
+			}
+
+			if expected := []int64{0, 1}; !reflect.DeepEqual(versions, expected) {
+				t.Fatalf("expected versions: %v, got: %v", expected, versions)
+			}
+
+			if versions, err = m.Apply(ctx, 0); err != nil {
+				t.Fatalf("migration apply failed: %s", err)
+			}
+
+			if expected := []int64{1}; !reflect.DeepEqual(versions, expected) {
+				t.Fatalf("expected versions: %v, got: %v", expected, versions)
+			}
+
+			if versions, err = m.Up(ctx); err != nil {
+				t.Fatalf("migration up failed: %s", err)
+			}
+
+			if expected := []int64{1}; !reflect.DeepEqual(versions, expected) {
+				t.Fatalf("expected versions: %v, got: %v", expected, versions)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("mock expectations failed: %s", err)
+			}
+		})
+	}
+}
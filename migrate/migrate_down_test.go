@@ -2,6 +2,7 @@ package migrate
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -76,10 +77,15 @@ func TestMigrationDown(t *testing.T) {
 		t.Fatalf("failed to create migrate: %s", err)
 	}
 
-	if err := m.Down(context.Background()); err != nil {
+	versions, err := m.Down(context.Background())
+	if err != nil {
 		t.Fatalf("migration run failed: %s", err)
 	}
 
+	if expected := []int64{4, 3, 2, 1, 0}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected versions: %v, got: %v", expected, versions)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("mock expectations failed: %s", err)
 	}
@@ -0,0 +1,211 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMigrationFunc(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	migration := &Migration{
+		Version: 1,
+		Name:    "users_email_lower",
+		Apply: Statements{
+			Statements: []string{"ALTER TABLE users ADD COLUMN email_lower text"},
+			Func: func(ctx context.Context, exec Executor) error {
+				_, err := exec.ExecContext(ctx, "UPDATE users SET email_lower = lower(email)")
+				return err
+			},
+		},
+		Discard: Statements{
+			Statements: []string{"ALTER TABLE users DROP COLUMN email_lower"},
+		},
+	}
+
+	// initial version check, version check returns 0
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration0.Version, time.Now(), migration0.Name),
+	)
+	mock.ExpectRollback()
+
+	// statements and function run within the same transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration0.Version, time.Now(), migration0.Name),
+	)
+	mock.ExpectExec(migration.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET email_lower = lower(email)").WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec(`INSERT INTO migrations(version,date,name) VALUES (1,NOW(),'users_email_lower')`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m, err := New(mdb, StdLog, []*Migration{migration})
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	versions, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatalf("migration run failed: %s", err)
+	}
+
+	if expected := []int64{1}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected versions: %v, got: %v", expected, versions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestMigrationFuncError(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	migration := &Migration{
+		Version: 1,
+		Name:    "users_email_lower",
+		Apply: Statements{
+			Statements: []string{"ALTER TABLE users ADD COLUMN email_lower text"},
+			Func: func(ctx context.Context, exec Executor) error {
+				return fmt.Errorf("transformation failed")
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration0.Version, time.Now(), migration0.Name),
+	)
+	mock.ExpectRollback()
+
+	// the failed function discards the migration statements and version bookkeeping
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration0.Version, time.Now(), migration0.Name),
+	)
+	mock.ExpectExec(migration.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	m, err := New(mdb, StdLog, []*Migration{migration})
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	if _, err = m.Up(context.Background()); err == nil {
+		t.Fatalf("expected migration function error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestMigrationCancel(t *testing.T) {
+	cases := []struct {
+		name       string
+		statements []string
+	}{
+		// the context is cancelled while the first statement runs, before the next statement
+		{name: "statement", statements: []string{"ALTER TABLE users ADD COLUMN email text", "UPDATE users SET email = name || '@email.com'"}},
+		// the context is cancelled while the last statement runs, before the migration function
+		{name: "func", statements: []string{"ALTER TABLE users ADD COLUMN email text"}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer mdb.Close()
+
+			called := false
+			migration := &Migration{
+				Version: 1,
+				Name:    "users_email",
+				Apply: Statements{
+					Statements: tt.statements,
+					// ignores the context, so it must not be called after cancellation
+					Func: func(ctx context.Context, exec Executor) error {
+						called = true
+						return nil
+					},
+				},
+				Discard: Statements{
+					Statements: []string{"ALTER TABLE users DROP COLUMN email"},
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// cancel the context as soon as the first statement executes
+			var executed []string
+			events := func(version int64, name, phase, stmt string, err error) {
+				executed = append(executed, stmt)
+				cancel()
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(versionQuery).WillReturnRows(
+				sqlmock.NewRows([]string{"date", "version", "name"}).
+					AddRow(migration0.Version, time.Now(), migration0.Name),
+			)
+			mock.ExpectRollback()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(versionQuery).WillReturnRows(
+				sqlmock.NewRows([]string{"date", "version", "name"}).
+					AddRow(migration0.Version, time.Now(), migration0.Name),
+			)
+			mock.ExpectExec(tt.statements[0]).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			m, err := New(mdb, StdLog, []*Migration{migration}, WithEventLogger(events))
+			if err != nil {
+				t.Fatalf("failed to create migrate: %s", err)
+			}
+
+			versions, err := m.Up(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled error, got: %v", err)
+			}
+
+			if len(versions) != 0 {
+				t.Fatalf("expected no applied versions, got: %v", versions)
+			}
+
+			// neither the remaining statements, the function or the version bookkeeping are executed
+			if expected := tt.statements[:1]; !reflect.DeepEqual(executed, expected) {
+				t.Fatalf("expected executed statements: %v, got: %v", expected, executed)
+			}
+
+			if called {
+				t.Fatalf("expected migration function not to be called after cancellation")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("mock expectations failed: %s", err)
+			}
+		})
+	}
+}
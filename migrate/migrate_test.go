@@ -1,9 +1,15 @@
 package migrate
 
 import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/brunotm/norm/statement"
 )
 
 func TestMigrateVersions(t *testing.T) {
@@ -22,3 +28,233 @@ func TestMigrateVersions(t *testing.T) {
 		t.Fatalf("wrong version count: %d, expected: %d, data %#v", len(versions), len(migrations)+1, versions)
 	}
 }
+
+func TestNewWithFilesPattern(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	files := fstest.MapFS{
+		"V1__users-table.up.pgsql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"V1__users-table.down.pgsql": {Data: []byte("DROP TABLE users;")},
+		"V2__roles-table.up.pgsql":   {Data: []byte("CREATE TABLE roles(id text);")},
+		"V2__roles-table.down.pgsql": {Data: []byte("DROP TABLE roles;")},
+	}
+
+	pattern := regexp.MustCompile(`V(\d+)__([\w-]+)\.(apply|discard)\.pgsql`)
+	if _, err = NewWithFiles(mdb, StdLog, files, WithPattern(pattern), WithStrict()); !errors.Is(err, ErrUnmatchedFile) {
+		t.Fatalf("expected unmatched file error, got: %v", err)
+	}
+
+	files = fstest.MapFS{
+		"V1__users-table.apply.pgsql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"V1__users-table.discard.pgsql": {Data: []byte("DROP TABLE users;")},
+		"V2__roles-table.apply.pgsql":   {Data: []byte("CREATE TABLE roles(id text);")},
+		"V2__roles-table.discard.pgsql": {Data: []byte("DROP TABLE roles;")},
+	}
+
+	m, err := NewWithFiles(mdb, StdLog, files, WithPattern(pattern), WithStrict())
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	versions := m.Versions()
+	if len(versions) != 3 || versions[1].Name != "users-table" || versions[2].Name != "roles-table" {
+		t.Fatalf("unexpected versions: %#v", versions)
+	}
+}
+
+func TestNewWithFilesStrict(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	files := fstest.MapFS{
+		"0001_users_table.apply.sql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"0001_users_table.discard.sql": {Data: []byte("DROP TABLE users;")},
+		"0002_roles-table.apply.sql":   {Data: []byte("CREATE TABLE roles(id text);")},
+	}
+
+	// without strict mode the unmatched file is skipped
+	m, err := NewWithFiles(mdb, StdLog, files)
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	if versions := m.Versions(); len(versions) != 2 {
+		t.Fatalf("wrong version count: %d, expected: %d, data %#v", len(versions), 2, versions)
+	}
+
+	if _, err = NewWithFiles(mdb, StdLog, files, WithStrict()); !errors.Is(err, ErrUnmatchedFile) {
+		t.Fatalf("expected unmatched file error, got: %v", err)
+	}
+}
+
+func TestNewWithFilesStrictMissingDirection(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	files := fstest.MapFS{
+		"0001_users_table.apply.sql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"0001_users_table.discard.sql": {Data: []byte("DROP TABLE users;")},
+		"0002_roles_table.apply.sql":   {Data: []byte("CREATE TABLE roles(id text);")},
+		"0003_index.discard.sql":       {Data: []byte("DROP INDEX users_id;")},
+	}
+
+	// without strict mode incomplete versions are accepted
+	if _, err = NewWithFiles(mdb, StdLog, files); err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	_, err = NewWithFiles(mdb, StdLog, files, WithStrict())
+	if !errors.Is(err, ErrMissingDirection) {
+		t.Fatalf("expected missing direction error, got: %v", err)
+	}
+
+	if expected := "2_roles_table.discard, 3_index.apply"; !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error to list: %s, got: %s", expected, err)
+	}
+}
+
+func TestNewWithFilesNested(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	files := fstest.MapFS{
+		"users/0001_users_table.apply.sql":        {Data: []byte("CREATE TABLE users(id text);")},
+		"users/0001_users_table.discard.sql":      {Data: []byte("DROP TABLE users;")},
+		"roles/0002_roles_table.apply.sql":        {Data: []byte("CREATE TABLE roles(id text);")},
+		"roles/0002_roles_table.discard.sql":      {Data: []byte("DROP TABLE roles;")},
+		"roles/fk/0003_user_roles_fk.apply.sql":   {Data: []byte("ALTER TABLE users ADD CONSTRAINT roles_fk FOREIGN KEY (role) REFERENCES roles (id);")},
+		"roles/fk/0003_user_roles_fk.discard.sql": {Data: []byte("ALTER TABLE users DROP CONSTRAINT roles_fk CASCADE;")},
+	}
+
+	m, err := NewWithFiles(mdb, StdLog, files, WithStrict())
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	versions := m.Versions()
+	if len(versions) != 4 || versions[3].Name != "user_roles_fk" {
+		t.Fatalf("unexpected versions: %#v", versions)
+	}
+}
+
+func TestNewWithFilesNestedDuplicate(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	files := fstest.MapFS{
+		"users/0001_users_table.apply.sql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"users/0001_users_table.discard.sql": {Data: []byte("DROP TABLE users;")},
+		"roles/0001_users_table.apply.sql":   {Data: []byte("CREATE TABLE users(id text);")},
+	}
+
+	if _, err = NewWithFiles(mdb, StdLog, files); !errors.Is(err, ErrDuplicateVersion) {
+		t.Fatalf("expected duplicate version error, got: %v", err)
+	}
+
+	files = fstest.MapFS{
+		"users/0001_users_table.apply.sql": {Data: []byte("CREATE TABLE users(id text);")},
+		"roles/1_roles_table.discard.sql":  {Data: []byte("DROP TABLE roles;")},
+	}
+
+	if _, err = NewWithFiles(mdb, StdLog, files); !errors.Is(err, ErrDuplicateVersion) {
+		t.Fatalf("expected duplicate version error, got: %v", err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"0002_roles_table.apply.sql":   {Data: []byte("CREATE TABLE roles(id text);\nCREATE INDEX roles_id ON roles(id);")},
+		"0002_roles_table.discard.sql": {Data: []byte("DROP TABLE roles;")},
+		"0001_users_table.apply.sql":   {Data: []byte("CREATE TABLE users(id text);")},
+		"0001_users_table.discard.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	migrations, err := LoadMigrations(files)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %s", err)
+	}
+
+	expected := []*Migration{
+		{
+			Version: 1,
+			Name:    "users_table",
+			Apply:   Statements{Statements: []string{"CREATE TABLE users(id text)"}},
+			Discard: Statements{Statements: []string{"DROP TABLE users"}},
+		},
+		{
+			Version: 2,
+			Name:    "roles_table",
+			Apply:   Statements{Statements: []string{"CREATE TABLE roles(id text)", "CREATE INDEX roles_id ON roles(id)"}},
+			Discard: Statements{Statements: []string{"DROP TABLE roles"}},
+		},
+	}
+
+	if !reflect.DeepEqual(migrations, expected) {
+		for _, m := range migrations {
+			t.Logf("%#v", m)
+		}
+		t.Fatalf("unexpected migrations")
+	}
+}
+
+func TestNewDialectNonAtomic(t *testing.T) {
+	mdb, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	// the roles table would be implicitly committed before the insert with MySQL
+	seeded := []*Migration{
+		migration1,
+		{
+			Version: 2,
+			Name:    "roles_seed",
+			Apply: MustParseStatements(`
+				CREATE TABLE roles(id text, PRIMARY KEY (id));
+				INSERT INTO roles(id) VALUES ('admin');`),
+			Discard: MustParseStatements("DROP TABLE roles;"),
+		},
+	}
+
+	var warnings []string
+	logger := func(s string, args ...interface{}) {
+		if strings.HasPrefix(s, "migrate: warning:") {
+			warnings = append(warnings, s)
+		}
+	}
+
+	if _, err = New(mdb, logger, seeded, WithDialect(statement.MySQLDialect)); err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected a single non atomic migration warning, got: %v", warnings)
+	}
+
+	if _, err = New(mdb, nil, seeded, WithDialect(statement.MySQLDialect), WithStrict()); !errors.Is(err, ErrNonAtomicMigration) {
+		t.Fatalf("expected ErrNonAtomicMigration, got: %v", err)
+	}
+
+	// transactional ddl
+	warnings = nil
+	if _, err = New(mdb, logger, seeded, WithDialect(statement.PostgresDialect), WithStrict()); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got: %v, error: %v", warnings, err)
+	}
+}
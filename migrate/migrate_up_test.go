@@ -3,6 +3,7 @@ package migrate
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -84,10 +85,144 @@ func TestMigrationUp(t *testing.T) {
 		t.Fatalf("failed to create migrate: %s", err)
 	}
 
-	if err := m.Up(context.Background()); err != nil {
+	versions, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatalf("migration run failed: %s", err)
+	}
+
+	if expected := []int64{0, 1, 2, 3, 4}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected versions: %v, got: %v", expected, versions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestMigrationApplyVersion(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	// initial version check, version check returns 1
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration1.Version, time.Now(), migration1.Name),
+	)
+	mock.ExpectRollback()
+
+	// initial version check for migration2, version check returns 1
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration1.Version, time.Now(), migration1.Name),
+	)
+	mock.ExpectExec(migration2.Apply.Statements[0]).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO migrations(version,date,name) VALUES (2,NOW(),'users_email_index')`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// initial version check for migration3, version check returns 2
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"date", "version", "name"}).
+			AddRow(migration2.Version, time.Now(), migration2.Name),
+	)
+	mock.ExpectExec(migration3.Apply.Statements[0]).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO migrations(version,date,name) VALUES (3,NOW(),'roles_table')`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m, err := New(mdb, StdLog, migrations)
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	versions, err := m.Apply(context.Background(), 3)
+	if err != nil {
 		t.Fatalf("migration run failed: %s", err)
 	}
 
+	if expected := []int64{2, 3}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected versions: %v, got: %v", expected, versions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestMigrationEvents(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	rows := func(mig *Migration) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"version", "date", "name"}).AddRow(mig.Version, time.Now(), mig.Name)
+	}
+
+	// initial version check, version check returns 1
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(rows(migration1))
+	mock.ExpectRollback()
+
+	// migration2 is applied
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(rows(migration1))
+	mock.ExpectExec(migration2.Apply.Statements[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO migrations(version,date,name) VALUES (2,NOW(),'users_email_index')`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// migration3 fails
+	failure := fmt.Errorf("permission denied")
+	mock.ExpectBegin()
+	mock.ExpectQuery(versionQuery).WillReturnRows(rows(migration2))
+	mock.ExpectExec(migration3.Apply.Statements[0]).WillReturnError(failure)
+	mock.ExpectRollback()
+
+	type event struct {
+		version int64
+		name    string
+		phase   string
+		stmt    string
+		err     error
+	}
+
+	var events []event
+	m, err := New(mdb, nil, migrations, WithEventLogger(func(version int64, name, phase, stmt string, err error) {
+		events = append(events, event{version, name, phase, stmt, err})
+	}))
+	if err != nil {
+		t.Fatalf("failed to create migrate: %s", err)
+	}
+
+	versions, err := m.Up(context.Background())
+	if err != failure {
+		t.Fatalf("expected migration error: %s, got: %v", failure, err)
+	}
+
+	if expected := []int64{2}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected versions: %v, got: %v", expected, versions)
+	}
+
+	expected := []event{
+		{2, "users_email_index", PhaseApply, migration2.Apply.Statements[0], nil},
+		{2, "users_email_index", PhaseVersion, `INSERT INTO migrations(version,date,name) VALUES (2,NOW(),'users_email_index')`, nil},
+		{3, "roles_table", PhaseApply, migration3.Apply.Statements[0], failure},
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events: %v, got: %v", expected, events)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("mock expectations failed: %s", err)
 	}
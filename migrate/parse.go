@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/brunotm/norm/statement"
 )
 
 var (
@@ -13,6 +15,50 @@ var (
 	noTXRegexp     = regexp.MustCompile(`--\s+migrate:\s+NoTransaction`)
 )
 
+// ParseStatements parses raw SQL with the same rules as migration files into Statements,
+// allowing migrations to be defined from raw SQL strings,
+// e.g. `Migration{Version: 1, Name: "users", Apply: migrate.MustParseStatements("CREATE TABLE users(id text);")}`.
+// Each statement must be terminated with `;` and transactions can be disabled
+// with the `-- migrate: NoTransaction` comment.
+func ParseStatements(sql string) (s Statements, err error) {
+	return parseStatement([]byte(sql))
+}
+
+// MustParseStatements is like ParseStatements but panics if the SQL cannot be parsed.
+// It simplifies the definition of migrations in variables.
+func MustParseStatements(sql string) (s Statements) {
+	s, err := ParseStatements(sql)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// BuildStatements builds the given statements into Statements, disabling transactions when a statement
+// can't run within a transaction, like `statement.CreateIndex(...).Concurrently()`,
+// in which case it must be the only statement.
+func BuildStatements(stmts ...statement.Statement) (s Statements, err error) {
+	s = Statements{}
+
+	for x := 0; x < len(stmts); x++ {
+		if t, ok := stmts[x].(statement.Transactional); ok && !t.Transactional() {
+			s.NoTx = true
+		}
+
+		query, err := stmts[x].String()
+		if err != nil {
+			return Statements{}, err
+		}
+		s.Statements = append(s.Statements, query)
+	}
+
+	if s.NoTx && len(s.Statements) > 1 {
+		return Statements{}, ErrInvalidNoTx
+	}
+
+	return s, nil
+}
+
 func parseStatement(data []byte) (s Statements, err error) {
 	s = Statements{}
 
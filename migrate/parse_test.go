@@ -3,6 +3,8 @@ package migrate
 import (
 	"reflect"
 	"testing"
+
+	"github.com/brunotm/norm/statement"
 )
 
 func TestParseSimple(t *testing.T) {
@@ -25,6 +27,35 @@ func TestParseMultiNoTx(t *testing.T) {
 	}
 }
 
+func TestParseStatementsMigration(t *testing.T) {
+	mig := &Migration{
+		Version: 1,
+		Name:    "users_table",
+		Apply: MustParseStatements(`
+			CREATE TABLE IF NOT EXISTS users(id text, name text);
+			CREATE INDEX IF NOT EXISTS ix_users_name ON users (name);`),
+		Discard: MustParseStatements("-- migrate: NoTransaction\nDROP TABLE IF EXISTS users;"),
+	}
+
+	expected := &Migration{
+		Version: 1,
+		Name:    "users_table",
+		Apply: Statements{Statements: []string{
+			"CREATE TABLE IF NOT EXISTS users(id text, name text)",
+			"CREATE INDEX IF NOT EXISTS ix_users_name ON users (name)",
+		}},
+		Discard: Statements{NoTx: true, Statements: []string{"DROP TABLE IF EXISTS users"}},
+	}
+
+	if !reflect.DeepEqual(expected, mig) {
+		t.Fatalf("expected: %#v got: %#v", expected, mig)
+	}
+
+	if _, err := ParseStatements("-- migrate: NoTransaction\n" + string(stmt)); err != ErrInvalidNoTx {
+		t.Fatalf("expected invalid no transaction error, got: %v", err)
+	}
+}
+
 var stmt = []byte(`
 CREATE TABLE IF NOT EXISTS users (
 	created_at timestamptz NOT NULL DEFAULT now(),
@@ -51,3 +82,35 @@ var expected = Statements{
 		"CREATE INDEX IF NOT EXISTS ix_users_updated_at ON users (updated_at)",
 	},
 }
+
+func TestBuildStatements(t *testing.T) {
+	s, err := BuildStatements(
+		statement.Create("TABLE ? (id text, tags text[])", "docs").IfNotExists(),
+		statement.CreateIndex("ix_docs_tags", "docs").Using("gin").Columns("tags"),
+	)
+	if err != nil {
+		t.Fatalf("failed to build statements: %s", err)
+	}
+
+	expected := Statements{Statements: []string{
+		"CREATE TABLE IF NOT EXISTS docs (id text, tags text[])",
+		"CREATE INDEX ix_docs_tags ON docs USING gin (tags)",
+	}}
+
+	if !reflect.DeepEqual(expected, s) {
+		t.Fatalf("expected: %#v got: %#v", expected, s)
+	}
+
+	// concurrent indexes disable transactions
+	if s, err = BuildStatements(statement.CreateIndex("ix_docs_tags", "docs").Columns("tags").Concurrently()); err != nil || !s.NoTx {
+		t.Fatalf("expected NoTx statements, got: %#v, error: %v", s, err)
+	}
+
+	_, err = BuildStatements(
+		statement.Create("TABLE ? (id text, tags text[])", "docs"),
+		statement.CreateIndex("ix_docs_tags", "docs").Columns("tags").Concurrently(),
+	)
+	if err != ErrInvalidNoTx {
+		t.Fatalf("expected ErrInvalidNoTx, got: %v", err)
+	}
+}
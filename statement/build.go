@@ -0,0 +1,177 @@
+package statement
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// checkInterval is the number of interpolated values between build context checks
+const checkInterval = 1024
+
+// buildBuffer is a Buffer carrying the state used to build a statement,
+// which is propagated to nested statements through their Build calls.
+type buildBuffer struct {
+	Buffer
+	ctx          context.Context
+	dialect      *Dialect
+	args         *[]interface{} // collected values when building parameterized statements
+	ordinal      bool           // write `$N` ordinal placeholders when building parameterized statements
+	skipComments bool           // skip comments when building fingerprints
+}
+
+// newBuildBuffer returns a buildBuffer for the given buffer,
+// inheriting the state if the buffer is already a buildBuffer.
+func newBuildBuffer(buf Buffer) (b *buildBuffer) {
+	if s, ok := buf.(*buildBuffer); ok {
+		b = &buildBuffer{}
+		*b = *s
+		return b
+	}
+
+	return &buildBuffer{Buffer: buf, dialect: defaultDialect}
+}
+
+// dialectOf returns the dialect carried by the given buffer, or the default dialect.
+func dialectOf(buf Buffer) (d *Dialect) {
+	if b, ok := buf.(*buildBuffer); ok {
+		return b.dialect
+	}
+	return defaultDialect
+}
+
+// withDialect returns a buffer building with the given dialect, or the buffer itself if the dialect is nil.
+// It is used by statements created from a Builder so that they and their nested statements
+// are built with the builder dialect.
+func withDialect(buf Buffer, d *Dialect) Buffer {
+	if d == nil {
+		return buf
+	}
+
+	b := newBuildBuffer(buf)
+	b.dialect = d
+	return b
+}
+
+// writeParam writes a placeholder for the given value and collects it as an argument
+// if the buffer is building a parameterized statement, returning whether it did so.
+func writeParam(buf Buffer, arg interface{}) (ok bool) {
+	b, ok := buf.(*buildBuffer)
+	if !ok || b.args == nil {
+		return false
+	}
+
+	*b.args = append(*b.args, arg)
+	if b.ordinal || b.dialect.OrdinalPlaceholders {
+		_, _ = b.WriteString("$")
+		_, _ = b.WriteString(strconv.Itoa(len(*b.args)))
+		return true
+	}
+
+	_, _ = b.WriteString("?")
+	return true
+}
+
+// buildErr returns the error of the build context carried by the given buffer, if any.
+func buildErr(buf Buffer) (err error) {
+	if b, ok := buf.(*buildBuffer); ok && b.ctx != nil {
+		return b.ctx.Err()
+	}
+	return nil
+}
+
+// BuildContext builds the statement into the given buffer, periodically checking the context
+// while interpolating values and returning the context error if it is done before the build completes.
+// It is useful for statements with a large number of values, like big `IN` lists.
+func BuildContext(ctx context.Context, buf Buffer, stmt Statement) (err error) {
+	b := newBuildBuffer(buf)
+	b.ctx = ctx
+	return stmt.Build(b)
+}
+
+// StringContext is like BuildContext, but returns the resulting query string.
+func StringContext(ctx context.Context, stmt Statement) (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = BuildContext(ctx, buf, stmt); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// BuildArgs builds the statement into the given buffer as a parameterized query, writing a `?`
+// placeholder for each value instead of interpolating it, and returns the values as arguments
+// for the database driver in placeholder order. Identifiers, keywords, comments and DDL
+// statements values are still interpolated.
+// Values are collected as the query is written, so the values of nested statements are always
+// ordered by the position of their placeholders in the resulting query.
+func BuildArgs(buf Buffer, stmt Statement) (args []interface{}, err error) {
+	b := newBuildBuffer(buf)
+	b.args = &args
+
+	if err = stmt.Build(b); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+// StringArgs is like BuildArgs, but returns the resulting query string and arguments.
+func StringArgs(stmt Statement) (q string, args []interface{}, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if args, err = BuildArgs(buf, stmt); err != nil {
+		return "", nil, err
+	}
+
+	return buf.String(), args, nil
+}
+
+// BuildOrdinalArgs is like BuildArgs, but writes `$1, $2, ...$N` ordinal placeholders,
+// as expected by PostgreSQL drivers like lib/pq and pgx.
+func BuildOrdinalArgs(buf Buffer, stmt Statement) (args []interface{}, err error) {
+	b := newBuildBuffer(buf)
+	b.args = &args
+	b.ordinal = true
+
+	if err = stmt.Build(b); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+// StringOrdinalArgs is like BuildOrdinalArgs, but returns the resulting query string and arguments.
+func StringOrdinalArgs(stmt Statement) (q string, args []interface{}, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if args, err = BuildOrdinalArgs(buf, stmt); err != nil {
+		return "", nil, err
+	}
+
+	return buf.String(), args, nil
+}
+
+// Fingerprint returns the statement query with all values replaced by `?` placeholders and comments
+// removed, which is stable across different values for the same statement and suitable for grouping
+// queries in metrics or logs. Statements with a different number of values, like `IN` lists of
+// different lengths, have different fingerprints.
+func Fingerprint(stmt Statement) (f string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	b := newBuildBuffer(buf)
+	b.args = &[]interface{}{}
+	b.skipComments = true
+
+	if err = stmt.Build(b); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,331 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelAfterContext is a context which is canceled after a number of Err() calls
+type cancelAfterContext struct {
+	context.Context
+	calls int
+	after int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.calls++
+	if c.calls > c.after {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestBuildContext(t *testing.T) {
+	values := make([]int, 1000000)
+	for x := 0; x < len(values); x++ {
+		values[x] = x
+	}
+
+	stmt := Select().Columns("id").From("users").WhereIn("id", values)
+
+	s, err := StringContext(context.Background(), stmt)
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expected, _ := stmt.String(); expected != s {
+		t.Fatalf("expected context build to match String()")
+	}
+
+	ctx := &cancelAfterContext{Context: context.Background(), after: 10}
+	if _, err = StringContext(ctx, stmt); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got: %v", err)
+	}
+
+	if ctx.calls != ctx.after+1 {
+		t.Fatalf("expected build to stop after cancellation, context checks: %d", ctx.calls)
+	}
+
+	ctx = &cancelAfterContext{Context: context.Background(), after: 10}
+	if _, err = StringContext(ctx, &Part{Query: "id IN (?)", Values: []interface{}{stmt}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error for nested statement, got: %v", err)
+	}
+}
+
+func TestStringArgs(t *testing.T) {
+	cases := []struct {
+		name    string
+		expect  string
+		args    []interface{}
+		stmt    Statement
+		wantErr bool
+	}{
+		{
+			name:   "select_limit_offset",
+			expect: "-- request id: 12435\nSELECT id FROM users WHERE role = ? AND id IN (?,?) LIMIT ? OFFSET ?",
+			args:   []interface{}{"admin", 1, 2, int64(10), int64(20)},
+			stmt: Select().Comment("request id: ?", 12435).Columns("id").From("users").
+				Where("role = ?", "admin").WhereIn("id", 1, 2).Limit(10).Offset(20),
+		},
+		{
+			name:   "select_subquery_fetch_first",
+			expect: "SELECT id FROM users WHERE role IN (SELECT name FROM roles WHERE active = ?) ORDER BY id ASC OFFSET ? ROWS FETCH FIRST ? ROWS ONLY",
+			args:   []interface{}{true, int64(0), int64(5)},
+			stmt: Select().Columns("id").From("users").
+				WhereIn("role", Select().Columns("name").From("roles").Where("active = ?", true)).
+				OrderAsc("id").FetchFirst(5, false),
+		},
+		{
+			name:   "select_scalar_subquery_column",
+			expect: "SELECT u.id,(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = ?) AS order_count FROM users AS u WHERE u.role = ?",
+			args:   []interface{}{"paid", "admin"},
+			stmt: Select().Columns("u.id").
+				Column("(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = ?) AS order_count", "paid").
+				From("users AS u").Where("u.role = ?", "admin"),
+		},
+		{
+			name:   "select_join_on",
+			expect: "SELECT u.id FROM users AS u INNER JOIN orders AS o ON o.user_id = u.id AND o.status = ? AND o.total > ? WHERE u.role = ?",
+			args:   []interface{}{"paid", 100, "admin"},
+			stmt: Select().Columns("u.id").From("users AS u").
+				JoinOn(InnerJoin, "orders AS o").On("o.user_id = u.id").And("o.status = ?", "paid").And("o.total > ?", 100).
+				Where("u.role = ?", "admin"),
+		},
+		{
+			name:   "update_default",
+			expect: "UPDATE users SET name = ?, role = DEFAULT WHERE id = ?",
+			args:   []interface{}{"john.doe", 123},
+			stmt:   Update().Table("users").Set("role", Default).Set("name", "john.doe").Where("id = ?", 123),
+		},
+		{
+			name:   "time_pointers",
+			expect: "INSERT INTO events(at,elapsed,missing) VALUES (?,?,?)",
+			args:   []interface{}{pointerTime, "5400 seconds", nil},
+			stmt:   Insert().Into("events").Columns("at", "elapsed", "missing").Values(&pointerTime, &pointerDuration, (*time.Duration)(nil)),
+		},
+		{
+			name:   "select_json_has_key",
+			expect: "SELECT id FROM t WHERE jsonb_exists(doc, ?) AND id = ?",
+			args:   []interface{}{"k", 5},
+			stmt:   Select().Columns("id").From("t").WhereJSONHasKey("doc", "k").Where("id = ?", 5),
+		},
+		{
+			name:    "negative_limit",
+			stmt:    Select().Columns("id").From("users").Limit(-1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, args, err := StringArgs(tt.stmt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error building statement: %v", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestStringArgsOrder(t *testing.T) {
+	// string values are named after the placeholder they must be bound to
+	cases := []struct {
+		name   string
+		expect string
+		args   []interface{}
+		stmt   Statement
+	}{
+		{
+			name: "select",
+			expect: "WITH active AS (SELECT id FROM users WHERE status = $1) " +
+				"(SELECT u.id,(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = $2) AS n " +
+				"FROM ( SELECT id,role_id FROM users WHERE tenant = $3 ) " +
+				"INNER JOIN roles AS r ON r.id = u.role_id AND r.active = $4 " +
+				"LEFT OUTER JOIN (SELECT user_id FROM logins WHERE day > $5) AS l ON l.user_id = u.id AND l.ok = $6 " +
+				"WHERE u.id IN (SELECT id FROM active) AND u.name = $7 AND u.kind IN ($8,$9) " +
+				"GROUP BY u.id HAVING count(*) > $10) " +
+				"UNION (SELECT id FROM admins WHERE level = $11) LIMIT $12 OFFSET $13",
+			args: []interface{}{"$1", "$2", "$3", "$4", "$5", "$6", "$7", "$8", "$9", "$10", "$11", int64(12), int64(13)},
+			stmt: Select().With("active", Select().Columns("id").From("users").Where("status = ?", "$1")).
+				Columns("u.id").Column("(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = ?) AS n", "$2").
+				From(Select().Columns("id", "role_id").From("users").Where("tenant = ?", "$3")).
+				JoinInner("roles AS r", "r.id = u.role_id AND r.active = ?", "$4").
+				JoinSub(LeftOuterJoin, Select().Columns("user_id").From("logins").Where("day > ?", "$5"), "l", "l.user_id = u.id AND l.ok = ?", "$6").
+				Where("u.id IN (SELECT id FROM active) AND u.name = ?", "$7").WhereIn("u.kind", "$8", "$9").
+				GroupBy("u.id").Having("count(*) > ?", "$10").
+				Union(Select().Columns("id").From("admins").Where("level = ?", "$11")).
+				Limit(12).Offset(13),
+		},
+		{
+			name: "update",
+			expect: "WITH stale AS (SELECT id FROM sessions WHERE expires_at < $1) " +
+				"UPDATE sessions SET note = $2, revoked = $3 WHERE id IN (SELECT id FROM stale) AND owner = $4 RETURNING id,$5 AS reason",
+			args: []interface{}{"$1", "$2", "$3", "$4", "$5"},
+			stmt: Update().With("stale", Select().Columns("id").From("sessions").Where("expires_at < ?", "$1")).
+				Table("sessions").Set("revoked", "$3").Set("note", "$2").
+				Where("id IN (SELECT id FROM stale)").Where("owner = ?", "$4").
+				Returning("id").ReturningExpr("? AS reason", "$5"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, args, err := StringOrdinalArgs(tt.stmt)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+
+			// `?` placeholders collect the same args in the same order
+			q, args, err := StringArgs(tt.stmt)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) || strings.Count(q, "?") != len(args) {
+				t.Fatalf("expected args: %#v, got: %#v for query: %s", tt.args, args, q)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := Fingerprint(Select().Comment("request id: ?", 1).Columns("id").From("users").
+		Where("role = ?", "admin").Where("created_at > ?", "2022-01-01").Limit(10))
+	if err != nil {
+		t.Fatalf("error building fingerprint: %s", err)
+	}
+
+	b, err := Fingerprint(Select().Comment("request id: ?", 2).Columns("id").From("users").
+		Where("role = ?", "owner").Where("created_at > ?", "2023-06-01").Limit(100))
+	if err != nil {
+		t.Fatalf("error building fingerprint: %s", err)
+	}
+
+	if expected := "SELECT id FROM users WHERE role = ? AND created_at > ? LIMIT ? OFFSET ?"; a != expected || b != expected {
+		t.Fatalf("expected: %s, got: %s and %s", expected, a, b)
+	}
+}
+
+func TestStringOrdinalArgs(t *testing.T) {
+	cases := []struct {
+		name   string
+		expect string
+		args   []interface{}
+		stmt   Statement
+	}{
+		{
+			name:   "select_join_where",
+			expect: "-- tenant: 'acme'\nSELECT u.id FROM users AS u INNER JOIN roles AS r ON r.id = u.role_id AND r.active = $1 INNER JOIN (SELECT user_id FROM orders WHERE total > $2) AS o ON o.user_id = u.id AND o.status = $3 WHERE u.name = $4 AND data ? 'owner' AND u.id IN ($5,$6) LIMIT $7 OFFSET $8",
+			args:   []interface{}{true, 100, "paid", "john", 1, 2, int64(10), int64(0)},
+			stmt: Select().Comment("tenant: ?", "acme").Columns("u.id").From("users AS u").
+				JoinInner("roles AS r", "r.id = u.role_id AND r.active = ?", true).
+				JoinSub(InnerJoin, Select().Columns("user_id").From("orders").Where("total > ?", 100), "o", "o.user_id = u.id AND o.status = ?", "paid").
+				Where("u.name = ?", "john").Where("data ?? 'owner'").WhereIn("u.id", 1, 2).Limit(10),
+		},
+		{
+			name:   "insert_values_cast",
+			expect: "INSERT INTO users(id,name) VALUES ($1::uuid,$2)",
+			args:   []interface{}{"6ba7b810-9dad-11d1-80b4-00c04fd430c8", "john"},
+			stmt:   Insert().Into("users").Columns("id", "name").ValuesCast([]string{"uuid"}, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "john"),
+		},
+		{
+			name:   "select_keyset_pagination",
+			expect: "SELECT id FROM events WHERE tenant = $1 AND (created_at, id) < ($2, $3) ORDER BY created_at DESC,id DESC LIMIT $4 OFFSET $5",
+			args:   []interface{}{"acme", "2021-01-01", 100, int64(10), int64(0)},
+			stmt: Select().Columns("id").From("events").Where("tenant = ?", "acme").
+				WhereRowLess([]string{"created_at", "id"}, "2021-01-01", 100).OrderBy("created_at DESC", "id DESC").Limit(10),
+		},
+		{
+			name:   "delete_where_named",
+			expect: "DELETE FROM events WHERE (owner = $1 OR assignee = $2) AND tenant = $3 AND at > '10:30'",
+			args:   []interface{}{"john", "john", "acme"},
+			stmt: Delete().From("events").
+				WhereNamed("(owner = :user OR assignee = :user) AND tenant = :tenant AND at > '10:30'", map[string]interface{}{"user": "john", "tenant": "acme"}),
+		},
+		{
+			name:   "insert_values",
+			expect: "INSERT INTO users(id,name,role) VALUES ($1,$2,DEFAULT),($3,$4,$5) RETURNING id",
+			args:   []interface{}{1, "john", 2, "jane", "admin"},
+			stmt:   Insert().Into("users").Columns("id", "name", "role").Values(1, "john", Default).Values(2, "jane", "admin").Returning("id"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, args, err := StringOrdinalArgs(tt.stmt)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestNamedPart(t *testing.T) {
+	cases := []struct {
+		name    string
+		expect  string
+		part    *NamedPart
+		wantErr bool
+	}{
+		{
+			name:   "quoted_literal",
+			expect: `x = 'a:b' AND y = 2`,
+			part:   &NamedPart{Query: "x = 'a:b' AND y = :y", Values: map[string]interface{}{"y": 2}},
+		},
+		{
+			name:   "quoted_literal_in_map",
+			expect: `x = 'a:b' AND y = 'it''s :b' AND z = 'b'`,
+			part:   &NamedPart{Query: "x = 'a:b' AND y = 'it''s :b' AND z = :b", Values: map[string]interface{}{"b": "b"}},
+		},
+		{
+			name:   "cast",
+			expect: `x = 1::text`,
+			part:   &NamedPart{Query: "x = :x::text", Values: map[string]interface{}{"x": 1}},
+		},
+		{
+			name:    "missing",
+			part:    &NamedPart{Query: "a = :a AND b = :b", Values: map[string]interface{}{"a": 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.part.String()
+			if tt.wantErr != errors.Is(err, ErrMissingNamedArg) || (!tt.wantErr && err != nil) {
+				t.Fatalf("unexpected error building part: %v", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+		})
+	}
+}
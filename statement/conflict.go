@@ -0,0 +1,166 @@
+package statement
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Conflict is a structured `ON CONFLICT target action` clause for an InsertStatement,
+// created with InsertStatement.OnConflictColumns or InsertStatement.OnConflictConstraint
+// and completed with DoNothing or DoUpdate, which return the insert statement.
+type Conflict struct {
+	insert  *InsertStatement
+	target  string
+	nothing bool
+	update  map[string]interface{}
+	where   []Statement
+}
+
+// OnConflictColumns adds a `ON CONFLICT (columns)` clause with the conflict target columns,
+// which must be completed with Conflict.DoNothing or Conflict.DoUpdate.
+func (s *InsertStatement) OnConflictColumns(columns ...string) *Conflict {
+	c := &Conflict{insert: s, target: "(" + strings.Join(columns, ",") + ")"}
+	s.onConflict = c
+	return c
+}
+
+// OnConflictConstraint adds a `ON CONFLICT ON CONSTRAINT name` clause with the conflict target constraint,
+// which must be completed with Conflict.DoNothing or Conflict.DoUpdate.
+func (s *InsertStatement) OnConflictConstraint(name string) *Conflict {
+	c := &Conflict{insert: s, target: "ON CONSTRAINT " + name}
+	s.onConflict = c
+	return c
+}
+
+// DoNothing sets the `DO NOTHING` conflict action and returns the insert statement.
+func (c *Conflict) DoNothing() *InsertStatement {
+	c.nothing = true
+	c.update = nil
+	return c.insert
+}
+
+// DoUpdate sets the `DO UPDATE SET column = value, ...` conflict action and returns the insert statement.
+// Columns are written in sorted order, use Ident to reference the proposed row values,
+// e.g. `DoUpdate(map[string]interface{}{"name": Ident("EXCLUDED.name")})`.
+func (c *Conflict) DoUpdate(set map[string]interface{}) *InsertStatement {
+	c.nothing = false
+	c.update = set
+	return c.insert
+}
+
+// WhereUpdate adds a `WHERE` condition to the `DO UPDATE` conflict action, so that only the conflicting rows
+// matching it are updated, e.g. `WhereUpdate("EXCLUDED.updated_at > users.updated_at")`.
+// Multiple calls to WhereUpdate are `ANDed` together.
+func (c *Conflict) WhereUpdate(q string, values ...interface{}) *Conflict {
+	c.where = append(c.where, &Part{Query: q, Values: values})
+	return c
+}
+
+// Build builds the statement into the given buffer.
+func (c *Conflict) Build(buf Buffer) (err error) {
+	if c.target == "()" {
+		return fmt.Errorf("statement: empty conflict target")
+	}
+
+	if dialectOf(buf).DuplicateKeyUpdate {
+		return c.buildDuplicateKey(buf)
+	}
+
+	_, _ = buf.WriteString("ON CONFLICT ")
+	_, _ = buf.WriteString(c.target)
+
+	if c.nothing {
+		if len(c.where) > 0 {
+			return fmt.Errorf("statement: conflict update condition requires DoUpdate")
+		}
+		_, _ = buf.WriteString(" DO NOTHING")
+		return nil
+	}
+
+	if len(c.update) == 0 {
+		return fmt.Errorf("statement: conflict requires a DoNothing or DoUpdate action")
+	}
+
+	_, _ = buf.WriteString(" DO UPDATE SET ")
+	if err = c.buildSet(buf, false); err != nil {
+		return err
+	}
+
+	return buildWhere(buf, c.where)
+}
+
+// buildDuplicateKey builds the conflict clause as `ON DUPLICATE KEY UPDATE column = value, ...`.
+func (c *Conflict) buildDuplicateKey(buf Buffer) (err error) {
+	if len(c.where) > 0 {
+		return fmt.Errorf("statement: conflict update condition not supported by dialect %s", dialectOf(buf).Name)
+	}
+
+	if c.nothing {
+		if !strings.HasPrefix(c.target, "(") || c.target == "()" {
+			return fmt.Errorf("statement: conflict DoNothing requires target columns for dialect %s", dialectOf(buf).Name)
+		}
+
+		column := strings.SplitN(c.target[1:len(c.target)-1], ",", 2)[0]
+		_, _ = buf.WriteString("ON DUPLICATE KEY UPDATE ")
+		_, _ = buf.WriteString(column)
+		_, _ = buf.WriteString(" = ")
+		_, _ = buf.WriteString(column)
+		return nil
+	}
+
+	if len(c.update) == 0 {
+		return fmt.Errorf("statement: conflict requires a DoNothing or DoUpdate action")
+	}
+
+	_, _ = buf.WriteString("ON DUPLICATE KEY UPDATE ")
+	return c.buildSet(buf, true)
+}
+
+// buildSet builds the `column = value, ...` conflict update list in sorted column order,
+// optionally writing `EXCLUDED.column` references as `VALUES(column)`.
+func (c *Conflict) buildSet(buf Buffer, duplicateKey bool) (err error) {
+	columns := make([]string, 0, len(c.update))
+	for column := range c.update {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for x := 0; x < len(columns); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+
+		_, _ = buf.WriteString(columns[x])
+		_, _ = buf.WriteString(" = ")
+
+		value := c.update[columns[x]]
+		if ident, ok := value.(Ident); ok && duplicateKey && len(ident) > len("EXCLUDED.") &&
+			strings.EqualFold(string(ident[:len("EXCLUDED.")]), "EXCLUDED.") {
+			_, _ = buf.WriteString("VALUES(")
+			_, _ = buf.WriteString(string(ident[len("EXCLUDED."):]))
+			_, _ = buf.WriteString(")")
+			continue
+		}
+
+		if err = writeValue(buf, value, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (c *Conflict) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = c.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
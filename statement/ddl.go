@@ -1,13 +1,62 @@
 package statement
 
-import "github.com/brunotm/norm/internal/buffer"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+var (
+	// ErrUnsupportedDDLClause will be returned when a clause is not supported for the DDL statement form
+	ErrUnsupportedDDLClause = fmt.Errorf("statement: unsupported clause for ddl statement")
+
+	ddlWordRegexp = regexp.MustCompile(`\S+`)
+
+	// ddlModifierKeywords are the keywords that may precede the object type
+	ddlModifierKeywords = map[string]bool{
+		"MATERIALIZED": true,
+		"TEMP":         true,
+		"TEMPORARY":    true,
+		"UNIQUE":       true,
+		"UNLOGGED":     true,
+	}
+
+	// ddlObjectKeywords are the object types of the supported DDL statement forms,
+	// mapped to the keyword that may follow them before the clause, if any
+	ddlObjectKeywords = map[string]string{
+		"EXTENSION": "",
+		"INDEX":     "CONCURRENTLY",
+		"SCHEMA":    "",
+		"SEQUENCE":  "",
+		"TABLE":     "",
+		"TYPE":      "",
+		"VIEW":      "",
+	}
+)
 
 // DDL represents a data definition statement.
 type DDL struct {
-	comment []Statement
+	comment     []Statement
+	ifExists    bool
+	ifNotExists bool
+	dialect     *Dialect
 	*Part
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original.
+func (s *DDL) Clone() *DDL {
+	c := *s
+	c.comment = append([]Statement(nil), s.comment...)
+	if s.Part != nil {
+		p := *s.Part
+		p.Values = append([]interface{}(nil), s.Part.Values...)
+		c.Part = &p
+	}
+	return &c
+}
+
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *DDL) Comment(c string, values ...interface{}) *DDL {
@@ -24,6 +73,35 @@ func (s *DDL) Comment(c string, values ...interface{}) *DDL {
 	return s
 }
 
+// BlockComment adds a `/* comment */` block comment to the generated query, written on the same line
+// before the statement, e.g. for query hints or sqlcommenter style tags.
+// Any comment delimiters within the text are escaped.
+func (s *DDL) BlockComment(c string) *DDL {
+	s.comment = append(s.comment, newBlockComment(c))
+	return s
+}
+
+// IfExists adds the `IF EXISTS` clause after the object type of `DROP` and `ALTER` statements,
+// e.g. `Drop("TABLE ?", "users").IfExists()` renders `DROP TABLE IF EXISTS users`.
+//
+// Supported object types are TABLE, INDEX, VIEW, SCHEMA, SEQUENCE, EXTENSION and TYPE, optionally
+// preceded by UNIQUE, TEMP, TEMPORARY, UNLOGGED or MATERIALIZED and for indexes followed by CONCURRENTLY.
+// Build returns an error for other statement forms.
+func (s *DDL) IfExists() *DDL {
+	s.ifExists = true
+	return s
+}
+
+// IfNotExists adds the `IF NOT EXISTS` clause after the object type of `CREATE` statements,
+// e.g. `Create("UNIQUE INDEX ? ON ? (?)", ...).IfNotExists()` renders `CREATE UNIQUE INDEX IF NOT EXISTS ...`.
+//
+// Supported object types are the same as for IfExists.
+// Build returns an error for other statement forms.
+func (s *DDL) IfNotExists() *DDL {
+	s.ifNotExists = true
+	return s
+}
+
 // Create creates a new `CREATE` DDL statement.
 func Create(query string, values ...interface{}) *DDL {
 	buf := buffer.New()
@@ -90,13 +168,200 @@ func Truncate(query string, values ...interface{}) *DDL {
 
 // Build builds the statement into the given buffer.
 func (s *DDL) Build(buf Buffer) (err error) {
-	for x := 0; x < len(s.comment); x++ {
-		if err = s.comment[x].Build(buf); err != nil {
+	buf = withDialect(buf, s.dialect)
+
+	if err = buildComments(buf, s.comment); err != nil {
+		return err
+	}
+
+	if !s.ifExists && !s.ifNotExists {
+		return s.build(buf, true)
+	}
+
+	query := s.Query
+	if s.ifExists {
+		if query, err = ddlInsertClause(query, "IF EXISTS", "DROP", "ALTER"); err != nil {
+			return err
+		}
+	}
+
+	if s.ifNotExists {
+		if query, err = ddlInsertClause(query, "IF NOT EXISTS", "CREATE"); err != nil {
 			return err
 		}
-		_, _ = buf.WriteString("\n")
 	}
-	return s.build(buf, true)
+
+	p := &Part{Query: query, Values: s.Values}
+	return p.build(buf, true)
+}
+
+// IndexStatement is a structured `CREATE INDEX` statement.
+type IndexStatement struct {
+	name         string
+	table        string
+	method       string
+	columns      []string
+	where        []Statement
+	unique       bool
+	concurrently bool
+	ifNotExists  bool
+	dialect      *Dialect
+}
+
+// CreateIndex creates a new `CREATE INDEX name ON table (columns)` statement.
+func CreateIndex(name, table string) (s *IndexStatement) {
+	return &IndexStatement{name: name, table: table}
+}
+
+// Columns adds the index columns, where each column is a verbatim index element allowing expressions,
+// operator classes and per column ordering, e.g. `Columns("lower(email)", "created_at DESC")`.
+func (s *IndexStatement) Columns(columns ...string) *IndexStatement {
+	s.columns = append(s.columns, columns...)
+	return s
+}
+
+// Using sets the index method, `USING method`, e.g. `Using("gin")`.
+func (s *IndexStatement) Using(method string) *IndexStatement {
+	s.method = method
+	return s
+}
+
+// Unique creates a `CREATE UNIQUE INDEX`.
+func (s *IndexStatement) Unique() *IndexStatement {
+	s.unique = true
+	return s
+}
+
+// IfNotExists adds a `IF NOT EXISTS` clause.
+func (s *IndexStatement) IfNotExists() *IndexStatement {
+	s.ifNotExists = true
+	return s
+}
+
+// Concurrently creates the index with `CREATE INDEX CONCURRENTLY` without locking writes to the table.
+// Concurrent indexes can't be created within a transaction, which is reported by Transactional.
+func (s *IndexStatement) Concurrently() *IndexStatement {
+	s.concurrently = true
+	return s
+}
+
+// Where adds a `WHERE predicate` clause creating a partial index, multiple calls to Where are `ANDed` together.
+// Values are always interpolated, as DDL statements can't be parameterized.
+func (s *IndexStatement) Where(predicate string, values ...interface{}) *IndexStatement {
+	s.where = append(s.where, &Part{Query: predicate, Values: values})
+	return s
+}
+
+// Transactional returns whether the statement can run within a transaction,
+// which is false for concurrent indexes.
+func (s *IndexStatement) Transactional() bool {
+	return !s.concurrently
+}
+
+// Build builds the statement into the given buffer.
+func (s *IndexStatement) Build(buf Buffer) (err error) {
+	if s.table == "" {
+		return fmt.Errorf("%w: create index requires a table", ErrMissingTable)
+	}
+
+	if len(s.columns) == 0 {
+		return fmt.Errorf("statement: create index requires columns")
+	}
+
+	if s.ifNotExists && s.name == "" {
+		return fmt.Errorf("statement: create index if not exists requires a name")
+	}
+
+	// values are interpolated even when building parameterized statements
+	b := newBuildBuffer(withDialect(buf, s.dialect))
+	b.args = nil
+
+	_, _ = b.WriteString("CREATE ")
+	if s.unique {
+		_, _ = b.WriteString("UNIQUE ")
+	}
+	_, _ = b.WriteString("INDEX ")
+	if s.concurrently {
+		_, _ = b.WriteString("CONCURRENTLY ")
+	}
+	if s.ifNotExists {
+		_, _ = b.WriteString("IF NOT EXISTS ")
+	}
+	if s.name != "" {
+		_, _ = b.WriteString(s.name)
+		_, _ = b.WriteString(" ")
+	}
+
+	_, _ = b.WriteString("ON ")
+	_, _ = b.WriteString(s.table)
+
+	if s.method != "" {
+		_, _ = b.WriteString(" USING ")
+		_, _ = b.WriteString(s.method)
+	}
+
+	_, _ = b.WriteString(" (")
+	_, _ = b.WriteString(strings.Join(s.columns, ", "))
+	_, _ = b.WriteString(")")
+
+	return buildWhere(b, s.where)
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *IndexStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ddlInsertClause inserts the clause after the object type keywords of the given query.
+// The query must start with one of the given verbs, followed by the object type modifiers, a single
+// object type and its trailing keyword, so that object names matching keywords are never consumed,
+// e.g. `DROP TABLE index` renders `DROP TABLE IF EXISTS index`.
+func ddlInsertClause(query, clause string, verbs ...string) (q string, err error) {
+	words := ddlWordRegexp.FindAllStringIndex(query, -1)
+	word := func(x int) string {
+		if x >= len(words) {
+			return ""
+		}
+		return strings.ToUpper(query[words[x][0]:words[x][1]])
+	}
+
+	supported := false
+	for _, v := range verbs {
+		supported = supported || v == word(0)
+	}
+
+	if !supported {
+		return "", fmt.Errorf("%w: %s, query: %s", ErrUnsupportedDDLClause, clause, query)
+	}
+
+	x := 1
+	for ddlModifierKeywords[word(x)] {
+		x++
+	}
+
+	trailing, ok := ddlObjectKeywords[word(x)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s, query: %s", ErrUnsupportedDDLClause, clause, query)
+	}
+
+	if trailing != "" && word(x+1) == trailing {
+		x++
+	}
+
+	// the clause is already present
+	if word(x+1) == "IF" {
+		return query, nil
+	}
+
+	end := words[x][1]
+	return query[:end] + " " + clause + query[end:], nil
 }
 
 // String builds the statement and returns the resulting query string.
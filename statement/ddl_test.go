@@ -15,6 +15,28 @@ var (
 			stmt:    Create("INDEX IF NOT EXISTS ? ON ? (?)", "ix_users_created_at", "users", "created_at"),
 			wantErr: false,
 		},
+		{
+			name:    "create_index_unique_partial_gin",
+			expect:  `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS ix_docs_tags ON docs USING gin (tags, data jsonb_path_ops) WHERE deleted_at IS NULL AND status = 'published'`,
+			stmt:    CreateIndex("ix_docs_tags", "docs").Unique().Concurrently().IfNotExists().Using("gin").Columns("tags", "data jsonb_path_ops").Where("deleted_at IS NULL").Where("status = ?", "published"),
+			wantErr: false,
+		},
+		{
+			name:    "create_index",
+			expect:  `CREATE INDEX ix_users_email ON users (lower(email))`,
+			stmt:    CreateIndex("ix_users_email", "users").Columns("lower(email)"),
+			wantErr: false,
+		},
+		{
+			name:    "create_index_without_columns",
+			stmt:    CreateIndex("ix_users_email", "users"),
+			wantErr: true,
+		},
+		{
+			name:    "create_index_if_not_exists_without_name",
+			stmt:    CreateIndex("", "users").Columns("email").IfNotExists(),
+			wantErr: true,
+		},
 		{
 			name:    "alter",
 			expect:  `ALTER TABLE users ADD COLUMN address text`,
@@ -33,6 +55,64 @@ var (
 			stmt:    Truncate("TABLE ? CASCADE", "users"),
 			wantErr: false,
 		},
+		{
+			name:    "create_if_not_exists",
+			expect:  `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS ix_users_email ON users (email)`,
+			stmt:    Create("UNIQUE INDEX CONCURRENTLY ? ON ? (?)", "ix_users_email", "users", "email").IfNotExists(),
+			wantErr: false,
+		},
+		{
+			name:    "create_table_if_not_exists",
+			expect:  `CREATE TABLE IF NOT EXISTS users (id text)`,
+			stmt:    Create("TABLE ? (id text)", "users").IfNotExists(),
+			wantErr: false,
+		},
+		{
+			name:    "drop_if_exists",
+			expect:  `DROP TABLE IF EXISTS users CASCADE`,
+			stmt:    Drop("TABLE ? CASCADE", "users").IfExists(),
+			wantErr: false,
+		},
+		{
+			name:    "drop_if_exists_present",
+			expect:  `DROP INDEX IF EXISTS ix_users_email`,
+			stmt:    Drop("INDEX IF EXISTS ?", "ix_users_email").IfExists(),
+			wantErr: false,
+		},
+		{
+			name:    "drop_if_exists_keyword_table",
+			expect:  `DROP TABLE IF EXISTS index CASCADE`,
+			stmt:    Drop("TABLE index CASCADE").IfExists(),
+			wantErr: false,
+		},
+		{
+			name:    "create_if_not_exists_keyword_table",
+			expect:  `CREATE TABLE IF NOT EXISTS type (view text, sequence int)`,
+			stmt:    Create("TABLE type (view text, sequence int)").IfNotExists(),
+			wantErr: false,
+		},
+		{
+			name:    "create_index_if_not_exists_keyword_name",
+			expect:  `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS table ON schema (index)`,
+			stmt:    Create("UNIQUE INDEX CONCURRENTLY table ON schema (index)").IfNotExists(),
+			wantErr: false,
+		},
+		{
+			name:    "create_materialized_view_if_not_exists",
+			expect:  `CREATE MATERIALIZED VIEW IF NOT EXISTS active_users AS SELECT id FROM users`,
+			stmt:    Create("MATERIALIZED VIEW active_users AS SELECT id FROM users").IfNotExists(),
+			wantErr: false,
+		},
+		{
+			name:    "drop_if_not_exists",
+			stmt:    Drop("TABLE ? CASCADE", "users").IfNotExists(),
+			wantErr: true,
+		},
+		{
+			name:    "create_unsupported",
+			stmt:    Create("OR REPLACE FUNCTION ?() RETURNS void", "noop").IfNotExists(),
+			wantErr: true,
+		},
 		{
 			name: "comment",
 			expect: `-- request id: 12435
@@ -57,3 +137,21 @@ func TestDDL(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateIndexArgs(t *testing.T) {
+	stmt := CreateIndex("ix_users_active", "users").Columns("email").Where("active = ?", true)
+
+	q, args, err := StringArgs(stmt)
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	// index predicates are always interpolated
+	if expected := `CREATE INDEX ix_users_active ON users (email) WHERE active = true`; q != expected || len(args) != 0 {
+		t.Fatalf("expected: %s, got: %s, args: %v", expected, q, args)
+	}
+
+	if !stmt.Transactional() || stmt.Concurrently().Transactional() {
+		t.Fatalf("expected only concurrent indexes to be non transactional")
+	}
+}
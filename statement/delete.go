@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/brunotm/norm/internal/buffer"
@@ -8,16 +9,34 @@ import (
 
 // DeleteStatement statement.
 type DeleteStatement struct {
-	table     string
-	with      Statement
-	comment   []Statement
-	where     []Statement
-	returning []string
+	table        string
+	using        []string
+	with         Statement
+	comment      []Statement
+	tailComments bool
+	where        []Statement
+	returning    []Statement
+	orderBy      []string
+	limitCount   int64
+	limitKey     string
+	dialect      *Dialect
 }
 
 // Delete creates a new `DELETE` statement.
 func Delete() (s *DeleteStatement) {
-	return &DeleteStatement{}
+	return &DeleteStatement{limitKey: "id"}
+}
+
+// Clone returns a copy of the statement that can be modified without affecting the original.
+// Nested statements like the with clause are shared with the original.
+func (s *DeleteStatement) Clone() *DeleteStatement {
+	c := *s
+	c.using = append([]string(nil), s.using...)
+	c.comment = append([]Statement(nil), s.comment...)
+	c.where = append([]Statement(nil), s.where...)
+	c.returning = append([]Statement(nil), s.returning...)
+	c.orderBy = append([]string(nil), s.orderBy...)
+	return &c
 }
 
 // Comment adds a SQL comment to the generated query.
@@ -36,43 +55,202 @@ func (s *DeleteStatement) Comment(c string, values ...interface{}) *DeleteStatem
 	return s
 }
 
+// BlockComment adds a `/* comment */` block comment to the generated query, written on the same line
+// before the statement, e.g. for query hints or sqlcommenter style tags.
+// Any comment delimiters within the text are escaped.
+func (s *DeleteStatement) BlockComment(c string) *DeleteStatement {
+	s.comment = append(s.comment, newBlockComment(c))
+	return s
+}
+
+// TrailingComments places the statement comments at the end of the statement instead of the beginning,
+// as expected by some tracing and APM tools, e.g. `SELECT ... /* traceparent='...' */`.
+func (s *DeleteStatement) TrailingComments() *DeleteStatement {
+	s.tailComments = true
+	return s
+}
+
 // From sets the table name or for the `FROM` clause.
 func (s *DeleteStatement) From(table string) *DeleteStatement {
 	s.table = table
 	return s
 }
 
+// FromAs sets the table name with an alias for the `FROM` clause, `DELETE FROM table AS alias`,
+// which can be referenced by the other clauses of the statement.
+func (s *DeleteStatement) FromAs(table, alias string) *DeleteStatement {
+	s.table = table + " AS " + alias
+	return s
+}
+
+// Using adds a `USING tables` clause, allowing columns from other tables to be referenced in the where clause.
+// Using appends to the tables already specified.
+func (s *DeleteStatement) Using(tables ...string) *DeleteStatement {
+	s.using = append(s.using, tables...)
+	return s
+}
+
 // With adds a `WITH alias AS (stmt)`
 func (s *DeleteStatement) With(alias string, stmt Statement) *DeleteStatement {
 	s.with = &with{alias: alias, stmt: stmt}
 	return s
 }
 
+// WithColumns adds a `WITH alias(columns...) AS (stmt)` with an explicit column list.
+func (s *DeleteStatement) WithColumns(alias string, columns []string, stmt Statement) *DeleteStatement {
+	s.with = &with{alias: alias, columns: columns, stmt: stmt}
+	return s
+}
+
 // Where adds a `WHERE` clause, multiple calls to Where are `ANDed` together.
 func (s *DeleteStatement) Where(q string, values ...interface{}) *DeleteStatement {
 	s.where = append(s.where, &Part{Query: q, Values: values})
 	return s
 }
 
+// WherePart adds a `WHERE` clause from an already built statement part, multiple calls to WherePart
+// and Where are `ANDed` together.
+func (s *DeleteStatement) WherePart(p Statement) *DeleteStatement {
+	s.where = append(s.where, p)
+	return s
+}
+
+// WhereNamed adds a `WHERE` clause with `:name` placeholders replaced by the values of the same name,
+// allowing a name to be used multiple times, e.g. `WhereNamed("a = :x OR b = :x", map[string]interface{}{"x": 1})`.
+// Multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereNamed(q string, values map[string]interface{}) *DeleteStatement {
+	s.where = append(s.where, &NamedPart{Query: q, Values: values})
+	return s
+}
+
+// WhereAll adds a `WHERE` clause for each of the given conditions, `ANDing` them together and with
+// any other where clauses, which is useful for filters assembled at runtime, e.g. from a []Statement.
+// Conditions are written as is, so conditions containing `OR` should be enclosed in parentheses.
+func (s *DeleteStatement) WhereAll(conditions ...Statement) *DeleteStatement {
+	s.where = append(s.where, conditions...)
+	return s
+}
+
 // WhereIn adds a `WHERE IN (values)` clause, multiple calls to WhereIn are `ANDed` together.
 func (s *DeleteStatement) WhereIn(column string, values ...interface{}) *DeleteStatement {
-	s.where = append(s.where, buildWhereIn(column, values...))
+	s.where = append(s.where, buildWhereIn(column, false, values...))
+	return s
+}
+
+// WhereNotIn adds a `WHERE NOT IN (values)` clause, multiple calls to WhereNotIn are `ANDed` together.
+func (s *DeleteStatement) WhereNotIn(column string, values ...interface{}) *DeleteStatement {
+	s.where = append(s.where, buildWhereIn(column, true, values...))
+	return s
+}
+
+// WhereAny adds a `WHERE value = ANY(column)` clause for membership in an array column,
+// multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereAny(value interface{}, column string) *DeleteStatement {
+	s.where = append(s.where, buildWhereAny(value, column))
+	return s
+}
+
+// WhereArrayContains adds a `WHERE column @> ARRAY[values...]` clause for array columns containing
+// all the given values, a single slice argument is expanded into the array values.
+// Building fails with ErrEmptyArray when there are no values.
+// Multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereArrayContains(column string, values ...interface{}) *DeleteStatement {
+	s.where = append(s.where, buildWhereArrayContains(column, values...))
+	return s
+}
+
+// WhereJSONText adds a `WHERE column->>'path' op value` clause comparing the text value of a json field,
+// e.g. `WhereJSONText("data", "status", "=", "active")`. Multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereJSONText(column, path, op string, value interface{}) *DeleteStatement {
+	s.where = append(s.where, buildWhereJSONText(column, path, op, value))
+	return s
+}
+
+// WhereJSONContains adds a `WHERE column @> value::jsonb` clause, where value is encoded as JSON
+// unless it is a string or []byte. Multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereJSONContains(column string, value interface{}) *DeleteStatement {
+	s.where = append(s.where, buildWhereJSONContains(column, value))
+	return s
+}
+
+// WhereJSONHasKey adds a `WHERE jsonb_exists(column, 'key')` clause, the function form of the jsonb `?` key
+// existence operator, which is not mistaken for a placeholder by drivers using `?` placeholders.
+// Multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereJSONHasKey(column, key string) *DeleteStatement {
+	s.where = append(s.where, buildWhereJSONHasKey(column, key))
+	return s
+}
+
+// WhereDistinctFrom adds a NULL safe `WHERE column IS DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereDistinctFrom(column string, value interface{}) *DeleteStatement {
+	s.where = append(s.where, &Part{Query: column + " IS DISTINCT FROM ?", Values: []interface{}{value}})
+	return s
+}
+
+// WhereNotDistinctFrom adds a NULL safe `WHERE column IS NOT DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *DeleteStatement) WhereNotDistinctFrom(column string, value interface{}) *DeleteStatement {
+	s.where = append(s.where, &Part{Query: column + " IS NOT DISTINCT FROM ?", Values: []interface{}{value}})
 	return s
 }
 
-// Returning adds a `RETURNING columns` clause.
+// Returning adds a `RETURNING columns` clause. Returning overwrites any previously returned columns or expressions.
 func (s *DeleteStatement) Returning(columns ...string) *DeleteStatement {
-	s.returning = columns
+	s.returning = buildReturning(columns)
+	return s
+}
+
+// ReturningExpr appends an expression to the `RETURNING` clause, e.g. `ReturningExpr("now() AS updated_at")`,
+// interpolating the given values. The expression is written as is and must not contain user provided input.
+func (s *DeleteStatement) ReturningExpr(expr string, values ...interface{}) *DeleteStatement {
+	s.returning = append(s.returning, &Part{Query: expr, Values: values})
+	return s
+}
+
+// OrderBy adds a `ORDER BY items` clause where each item is a verbatim `ORDER BY` element,
+// determining which rows are deleted when used with Limit.
+// OrderBy overwrites any previously set ordering.
+func (s *DeleteStatement) OrderBy(items ...string) *DeleteStatement {
+	s.orderBy = items
+	return s
+}
+
+// Limit adds a `LIMIT n` clause, deleting at most n rows, e.g. for deleting in bounded batches.
+//
+// Dialects without support for `ORDER BY` and `LIMIT` in DELETE statements, like PostgreSQL,
+// emulate them with a subquery selecting the keys of the rows to delete,
+// `DELETE FROM table WHERE table.id IN (SELECT table.id FROM table WHERE ... ORDER BY ... LIMIT n)`.
+// The key column can be set with LimitKey and is qualified with the table name or alias.
+func (s *DeleteStatement) Limit(n int64) *DeleteStatement {
+	s.limitCount = n
+	return s
+}
+
+// LimitKey sets the column identifying the rows to delete used when OrderBy or Limit are emulated
+// with a subquery, `id` by default. It can be any unique column like `ctid` in PostgreSQL.
+// Unqualified keys are qualified with the table name or alias, so they are not ambiguous with the using tables.
+func (s *DeleteStatement) LimitKey(column string) *DeleteStatement {
+	s.limitKey = column
 	return s
 }
 
 // Build builds the statement into the given buffer.
 func (s *DeleteStatement) Build(buf Buffer) (err error) {
-	for x := 0; x < len(s.comment); x++ {
-		if err = s.comment[x].Build(buf); err != nil {
+	buf = withDialect(buf, s.dialect)
+
+	if s.table == "" {
+		return fmt.Errorf("%w: delete requires From", ErrMissingTable)
+	}
+
+	if s.limitCount < 0 {
+		return fmt.Errorf("statement: delete limit must not be negative, limit: %d", s.limitCount)
+	}
+
+	if !s.tailComments {
+		if err = buildComments(buf, s.comment); err != nil {
 			return err
 		}
-		_, _ = buf.WriteString("\n")
 	}
 
 	if s.with != nil {
@@ -84,15 +262,62 @@ func (s *DeleteStatement) Build(buf Buffer) (err error) {
 
 	_, _ = buf.WriteString("DELETE FROM ")
 	_, _ = buf.WriteString(s.table)
+
+	if (len(s.orderBy) > 0 || s.limitCount > 0) && !dialectOf(buf).DMLLimit {
+		if err = s.buildLimitSubquery(buf); err != nil {
+			return err
+		}
+	} else {
+		if len(s.using) > 0 {
+			_, _ = buf.WriteString(" USING ")
+			_, _ = buf.WriteString(strings.Join(s.using, ", "))
+		}
+
+		if err = buildWhere(buf, s.where); err != nil {
+			return err
+		}
+
+		if err = buildOrderLimit(buf, s.orderBy, s.limitCount); err != nil {
+			return err
+		}
+	}
+
+	if err = buildList(buf, " RETURNING ", s.returning); err != nil {
+		return err
+	}
+
+	if s.tailComments {
+		return buildTrailingComments(buf, s.comment)
+	}
+
+	return nil
+}
+
+// buildLimitSubquery writes the `WHERE key IN (SELECT key FROM table ... ORDER BY ... LIMIT n)` clause
+// emulating OrderBy and Limit, where the using tables are joined in the subquery.
+func (s *DeleteStatement) buildLimitSubquery(buf Buffer) (err error) {
+	key := qualifiedKey(s.table, s.limitKey)
+	_, _ = buf.WriteString(" WHERE ")
+	_, _ = buf.WriteString(key)
+	_, _ = buf.WriteString(" IN (SELECT ")
+	_, _ = buf.WriteString(key)
+	_, _ = buf.WriteString(" FROM ")
+	_, _ = buf.WriteString(s.table)
+
+	for x := 0; x < len(s.using); x++ {
+		_, _ = buf.WriteString(", ")
+		_, _ = buf.WriteString(s.using[x])
+	}
+
 	if err = buildWhere(buf, s.where); err != nil {
 		return err
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if err = buildOrderLimit(buf, s.orderBy, s.limitCount); err != nil {
+		return err
 	}
 
+	_, _ = buf.WriteString(")")
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -23,13 +24,54 @@ var (
 			stmt:    Delete().From("users").WhereIn("role", "admin", "owner"),
 			wantErr: false,
 		},
+		{
+			name:    "where_not_in",
+			expect:  `DELETE FROM users WHERE role NOT IN ('admin','owner')`,
+			stmt:    Delete().From("users").WhereNotIn("role", "admin", "owner"),
+			wantErr: false,
+		},
 		{
 			name:   "with",
-			expect: `WITH roles_to_delete AS (SELECT id,name FROM roles WHERE expires_at < now()-'1m'::interval) DELETE FROM users WHERE role IN ((SELECT name FROM roles_to_delete))`,
+			expect: `WITH roles_to_delete AS (SELECT id,name FROM roles WHERE expires_at < now()-'1m'::interval) DELETE FROM users WHERE role IN (SELECT name FROM roles_to_delete)`,
 			stmt: Delete().With("roles_to_delete", Select().Columns("id", "name").From("roles").Where("expires_at < now()-?::interval", "1m")).
 				From("users").WhereIn("role", Select().Columns("name").From("roles_to_delete")),
 			wantErr: false,
 		},
+		{
+			name:   "with_columns",
+			expect: `WITH expired(role) AS (SELECT name FROM roles WHERE expires_at < now()) DELETE FROM users WHERE role IN (SELECT role FROM expired)`,
+			stmt: Delete().WithColumns("expired", []string{"role"}, Select().Columns("name").From("roles").Where("expires_at < now()")).
+				From("users").WhereIn("role", Select().Columns("role").From("expired")),
+			wantErr: false,
+		},
+		{
+			name:    "where_array_contains",
+			expect:  `DELETE FROM users WHERE roles @> ARRAY['admin','owner'] AND 123 = ANY(blocked_by)`,
+			stmt:    Delete().From("users").WhereArrayContains("roles", "admin", "owner").WhereAny(123, "blocked_by"),
+			wantErr: false,
+		},
+		{
+			name: "with_using_returning",
+			expect: `WITH expired AS (SELECT id FROM accounts WHERE expires_at < '2022-01-01') ` +
+				`DELETE FROM sessions USING users, expired WHERE sessions.user_id = users.id AND users.account_id = expired.id AND users.role = 'guest' RETURNING sessions.id`,
+			stmt: Delete().With("expired", Select().Columns("id").From("accounts").Where("expires_at < ?", "2022-01-01")).
+				From("sessions").Using("users", "expired").
+				Where("sessions.user_id = users.id").Where("users.account_id = expired.id").Where("users.role = ?", "guest").
+				Returning("sessions.id"),
+			wantErr: false,
+		},
+		{
+			name:    "alias",
+			expect:  `DELETE FROM myschema.users AS u WHERE u.id = 123`,
+			stmt:    Delete().FromAs("myschema.users", "u").Where("u.id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "where_part",
+			expect:  `DELETE FROM users WHERE active = true AND deleted_at IS NULL AND role = 'admin'`,
+			stmt:    Delete().From("users").WherePart(activeUsers).Where("role = ?", "admin"),
+			wantErr: false,
+		},
 		{
 			name:    "returning",
 			expect:  `DELETE FROM users WHERE email = 'john.doe@email.com' AND role = 'admin' RETURNING id`,
@@ -41,6 +83,47 @@ var (
 			stmt:    Delete().From("users").Where("email = ?").Where("role = ?", "admin").Returning("id"),
 			wantErr: true,
 		},
+		{
+			name:    "order_limit_mysql",
+			expect:  `DELETE FROM events WHERE created_at < '2021-01-01' ORDER BY created_at,id LIMIT 1000`,
+			stmt:    MySQL().Delete().From("events").Where("created_at < ?", "2021-01-01").OrderBy("created_at", "id").Limit(1000),
+			wantErr: false,
+		},
+		{
+			name:    "order_limit_subquery",
+			expect:  `DELETE FROM events WHERE events.id IN (SELECT events.id FROM events WHERE created_at < '2021-01-01' ORDER BY created_at,id LIMIT 1000) RETURNING id`,
+			stmt:    Delete().From("events").Where("created_at < ?", "2021-01-01").OrderBy("created_at", "id").Limit(1000).Returning("id"),
+			wantErr: false,
+		},
+		{
+			name:    "limit_subquery_key_using",
+			expect:  `DELETE FROM events AS e WHERE e.ctid IN (SELECT e.ctid FROM events AS e, users AS u WHERE e.user_id = u.id AND u.active = false LIMIT 10)`,
+			stmt:    Delete().FromAs("events", "e").Using("users AS u").Where("e.user_id = u.id AND u.active = ?", false).LimitKey("e.ctid").Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "limit_subquery_default_key_using",
+			expect:  `DELETE FROM events WHERE events.id IN (SELECT events.id FROM events, users WHERE events.user_id = users.id AND users.active = false LIMIT 10)`,
+			stmt:    Delete().From("events").Using("users").Where("events.user_id = users.id AND users.active = ?", false).Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "limit_subquery_default_key_alias",
+			expect:  `DELETE FROM events AS e WHERE e.id IN (SELECT e.id FROM events AS e, users AS u WHERE e.user_id = u.id AND u.active = false LIMIT 10)`,
+			stmt:    Delete().FromAs("events", "e").Using("users AS u").Where("e.user_id = u.id AND u.active = ?", false).Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "negative_limit",
+			stmt:    Delete().From("events").Limit(-1),
+			wantErr: true,
+		},
+		{
+			name:    "block_comment",
+			expect:  `/* tenant='acme' */ DELETE FROM users WHERE id = 123`,
+			stmt:    Delete().BlockComment("tenant='acme'").From("users").Where("id = ?", 123),
+			wantErr: false,
+		},
 		{
 			name: "comment",
 			expect: `-- request id: 12435
@@ -65,3 +148,9 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteMissingTable(t *testing.T) {
+	if _, err := Delete().Where("id = ?", 123).String(); !errors.Is(err, ErrMissingTable) {
+		t.Fatalf("expected missing table error, got: %v", err)
+	}
+}
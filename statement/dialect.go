@@ -0,0 +1,150 @@
+package statement
+
+import "github.com/brunotm/norm/internal/buffer"
+
+// Dialect defines the database specific rules used when building statements.
+// The zero value builds statements using the default, PostgreSQL compatible, rules.
+type Dialect struct {
+	// Name is the dialect name
+	Name string
+
+	// BoolAsInt renders boolean values as 1 and 0 instead of true and false
+	BoolAsInt bool
+
+	// OrdinalPlaceholders writes `$1, $2, ...$N` placeholders instead of `?`
+	// when building parameterized statements
+	OrdinalPlaceholders bool
+
+	// DMLLimit supports `ORDER BY` and `LIMIT` clauses in DELETE and UPDATE statements,
+	// which are otherwise emulated with a subquery selecting the keys of the affected rows
+	DMLLimit bool
+
+	// DDLAutoCommit DDL statements implicitly commit the current transaction,
+	// so they can't be combined atomically with other statements in a transaction
+	DDLAutoCommit bool
+
+	// DuplicateKeyUpdate renders conflict and upsert clauses as `ON DUPLICATE KEY UPDATE column = value`
+	// instead of `ON CONFLICT target DO UPDATE SET column = value`. The conflict target is implied by the
+	// table unique keys, `EXCLUDED.column` references are written as `VALUES(column)` and `DO NOTHING`
+	// is emulated by updating the first target column to itself
+	DuplicateKeyUpdate bool
+
+	// BackslashEscapes string literals treat backslash as an escape character,
+	// so backslashes in interpolated strings are escaped as `\\` and bytes are written as `X'hex'`
+	BackslashEscapes bool
+}
+
+var (
+	// PostgresDialect builds statements for PostgreSQL
+	PostgresDialect = &Dialect{Name: "postgres", OrdinalPlaceholders: true}
+
+	// MySQLDialect builds statements for MySQL
+	MySQLDialect = &Dialect{Name: "mysql", BoolAsInt: true, DMLLimit: true, DDLAutoCommit: true, DuplicateKeyUpdate: true, BackslashEscapes: true}
+
+	// SQLiteDialect builds statements for SQLite
+	SQLiteDialect = &Dialect{Name: "sqlite", BoolAsInt: true}
+
+	defaultDialect = &Dialect{}
+)
+
+// Build builds the statement into the given buffer using the dialect rules.
+// Statements created from a Builder are always built with the builder dialect.
+func (d *Dialect) Build(buf Buffer, stmt Statement) (err error) {
+	b := newBuildBuffer(buf)
+	b.dialect = d
+	return stmt.Build(b)
+}
+
+// String builds the statement using the dialect rules and returns the resulting query string.
+func (d *Dialect) String(stmt Statement) (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = d.Build(buf, stmt); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Builder creates statements which are always built with its dialect, so that all statements created
+// from the same builder share the dialect rules regardless of how they are built, including
+// the placeholders written when building parameterized statements with BuildArgs or StringArgs.
+type Builder struct {
+	dialect *Dialect
+}
+
+// NewBuilder creates a new Builder for the given dialect.
+func NewBuilder(d *Dialect) (b *Builder) {
+	return &Builder{dialect: d}
+}
+
+// Postgres creates a new Builder for the PostgreSQL dialect.
+func Postgres() (b *Builder) {
+	return NewBuilder(PostgresDialect)
+}
+
+// MySQL creates a new Builder for the MySQL dialect.
+func MySQL() (b *Builder) {
+	return NewBuilder(MySQLDialect)
+}
+
+// SQLite creates a new Builder for the SQLite dialect.
+func SQLite() (b *Builder) {
+	return NewBuilder(SQLiteDialect)
+}
+
+// Dialect returns the builder dialect.
+func (b *Builder) Dialect() (d *Dialect) {
+	return b.dialect
+}
+
+// Select creates a new select statement using the builder dialect.
+func (b *Builder) Select() (s *SelectStatement) {
+	s = Select()
+	s.dialect = b.dialect
+	return s
+}
+
+// Insert creates a new insert statement using the builder dialect.
+func (b *Builder) Insert() (s *InsertStatement) {
+	s = Insert()
+	s.dialect = b.dialect
+	return s
+}
+
+// Upsert creates a new upsert statement for the given table and struct record using the builder dialect,
+// rendering `ON CONFLICT (keys) DO UPDATE` or `ON DUPLICATE KEY UPDATE` as supported by the dialect.
+func (b *Builder) Upsert(table string, record interface{}, keys ...string) (s *InsertStatement) {
+	s = Upsert(table, record, keys...)
+	s.dialect = b.dialect
+	return s
+}
+
+// Update creates a new update statement using the builder dialect.
+func (b *Builder) Update() (s *UpdateStatement) {
+	s = Update()
+	s.dialect = b.dialect
+	return s
+}
+
+// Delete creates a new delete statement using the builder dialect.
+func (b *Builder) Delete() (s *DeleteStatement) {
+	s = Delete()
+	s.dialect = b.dialect
+	return s
+}
+
+// Create creates a new `CREATE` DDL statement using the builder dialect.
+func (b *Builder) Create(query string, values ...interface{}) (s *DDL) {
+	s = Create(query, values...)
+	s.dialect = b.dialect
+	return s
+}
+
+// CreateIndex creates a new `CREATE INDEX` statement using the builder dialect.
+func (b *Builder) CreateIndex(name, table string) (s *IndexStatement) {
+	s = CreateIndex(name, table)
+	s.dialect = b.dialect
+	return s
+}
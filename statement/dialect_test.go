@@ -0,0 +1,235 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	dialectCases = []struct {
+		name    string
+		expect  string
+		dialect *Dialect
+		stmt    Statement
+		wantErr bool
+	}{
+		{
+			name:    "default_bool",
+			expect:  `SELECT id FROM users WHERE active = true AND deleted = false`,
+			dialect: defaultDialect,
+			stmt:    Select().Columns("id").From("users").Where("active = ? AND deleted = ?", true, false),
+			wantErr: false,
+		},
+		{
+			name:    "postgres_bool",
+			expect:  `SELECT id FROM users WHERE active = true AND deleted = false`,
+			dialect: PostgresDialect,
+			stmt:    Select().Columns("id").From("users").Where("active = ? AND deleted = ?", true, false),
+			wantErr: false,
+		},
+		{
+			name:    "mysql_bool",
+			expect:  `SELECT id FROM users WHERE active = 1 AND deleted = 0`,
+			dialect: MySQLDialect,
+			stmt:    Select().Columns("id").From("users").Where("active = ? AND deleted = ?", true, false),
+			wantErr: false,
+		},
+		{
+			name:    "sqlite_bool_nested",
+			expect:  `SELECT id FROM users WHERE role IN (SELECT name FROM roles WHERE active = 1)`,
+			dialect: SQLiteDialect,
+			stmt:    Select().Columns("id").From("users").WhereIn("role", Select().Columns("name").From("roles").Where("active = ?", true)),
+			wantErr: false,
+		},
+		{
+			name:    "mysql_backslash_escape",
+			expect:  `SELECT id FROM users WHERE name = '\\'' OR 1=1 -- ' AND data = X'0aff'`,
+			dialect: MySQLDialect,
+			stmt:    Select().Columns("id").From("users").Where("name = ? AND data = ?", "\\' OR 1=1 -- ", []byte{0x0a, 0xff}),
+			wantErr: false,
+		},
+		{
+			name:    "postgres_backslash",
+			expect:  `SELECT id FROM users WHERE name = '\'' OR 1=1 -- '`,
+			dialect: PostgresDialect,
+			stmt:    Select().Columns("id").From("users").Where("name = ?", "\\' OR 1=1 -- "),
+			wantErr: false,
+		},
+	}
+)
+
+func TestDialect(t *testing.T) {
+	for _, tt := range dialectCases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.dialect.String(tt.stmt)
+			if !tt.wantErr && err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+		})
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	query := func(b *Builder) Statement {
+		return b.Select().Columns("id").From("users").
+			Where("active = ?", true).
+			WhereIn("role", b.Select().Columns("name").From("roles").Where("admin = ?", false))
+	}
+
+	cases := []struct {
+		name       string
+		builder    *Builder
+		expect     string
+		expectArgs string
+	}{
+		{
+			name:       "postgres",
+			builder:    Postgres(),
+			expect:     `SELECT id FROM users WHERE active = true AND role IN (SELECT name FROM roles WHERE admin = false)`,
+			expectArgs: `SELECT id FROM users WHERE active = $1 AND role IN (SELECT name FROM roles WHERE admin = $2)`,
+		},
+		{
+			name:       "mysql",
+			builder:    MySQL(),
+			expect:     `SELECT id FROM users WHERE active = 1 AND role IN (SELECT name FROM roles WHERE admin = 0)`,
+			expectArgs: `SELECT id FROM users WHERE active = ? AND role IN (SELECT name FROM roles WHERE admin = ?)`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := query(tt.builder)
+
+			s, err := stmt.String()
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			// the builder dialect takes precedence over the dialect used to build
+			if s, _ = defaultDialect.String(stmt); tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			s, args, err := StringArgs(stmt)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expectArgs != s {
+				t.Fatalf("expected: %s, got: %s", tt.expectArgs, s)
+			}
+
+			if expected := []interface{}{true, false}; !reflect.DeepEqual(args, expected) {
+				t.Fatalf("expected args: %v, got: %v", expected, args)
+			}
+		})
+	}
+
+	s, err := MySQL().Select().Columns("id").From("users").Where("name = ?", "\\' OR 1=1 -- ").String()
+	if expected := `SELECT id FROM users WHERE name = '\\'' OR 1=1 -- '`; err != nil || s != expected {
+		t.Fatalf("expected: %s, got: %s, error: %v", expected, s, err)
+	}
+
+	s, err = SQLite().Update().Table("users").Set("active", true).Where("id = ?", 1).String()
+	if expected := `UPDATE users SET active = 1 WHERE id = 1`; err != nil || s != expected {
+		t.Fatalf("expected: %s, got: %s, error: %v", expected, s, err)
+	}
+}
+
+func TestBuilderUpsert(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	cases := []struct {
+		name    string
+		stmt    func(b *Builder) Statement
+		expect  map[string]string
+		wantErr map[string]bool
+	}{
+		{
+			name: "upsert",
+			stmt: func(b *Builder) Statement {
+				return b.Upsert("users", user{ID: 1, Name: "john", Email: "john@doe.com"}, "id")
+			},
+			expect: map[string]string{
+				"postgres": `INSERT INTO users(id,name,email) VALUES (1,'john','john@doe.com') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`,
+				"mysql":    `INSERT INTO users(id,name,email) VALUES (1,'john','john@doe.com') ON DUPLICATE KEY UPDATE name = VALUES(name), email = VALUES(email)`,
+				"sqlite":   `INSERT INTO users(id,name,email) VALUES (1,'john','john@doe.com') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`,
+			},
+		},
+		{
+			name: "upsert_all_keys",
+			stmt: func(b *Builder) Statement {
+				return b.Insert().Into("users_roles").Columns("user_id", "role").Values(1, "admin").UpsertOnConflict("user_id", "role")
+			},
+			expect: map[string]string{
+				"postgres": `INSERT INTO users_roles(user_id,role) VALUES (1,'admin') ON CONFLICT (user_id,role) DO NOTHING`,
+				"mysql":    `INSERT INTO users_roles(user_id,role) VALUES (1,'admin') ON DUPLICATE KEY UPDATE user_id = user_id`,
+				"sqlite":   `INSERT INTO users_roles(user_id,role) VALUES (1,'admin') ON CONFLICT (user_id,role) DO NOTHING`,
+			},
+		},
+		{
+			name: "conflict_do_update",
+			stmt: func(b *Builder) Statement {
+				return b.Insert().Into("users").Columns("id", "name").Values(1, "john").
+					OnConflictColumns("id").DoUpdate(map[string]interface{}{"name": Ident("EXCLUDED.name"), "active": true})
+			},
+			expect: map[string]string{
+				"postgres": `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO UPDATE SET active = true, name = EXCLUDED.name`,
+				"mysql":    `INSERT INTO users(id,name) VALUES (1,'john') ON DUPLICATE KEY UPDATE active = 1, name = VALUES(name)`,
+				"sqlite":   `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO UPDATE SET active = 1, name = EXCLUDED.name`,
+			},
+		},
+		{
+			name: "conflict_do_nothing",
+			stmt: func(b *Builder) Statement {
+				return b.Insert().Into("users").Columns("id", "name").Values(1, "john").OnConflictColumns("id").DoNothing()
+			},
+			expect: map[string]string{
+				"postgres": `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO NOTHING`,
+				"mysql":    `INSERT INTO users(id,name) VALUES (1,'john') ON DUPLICATE KEY UPDATE id = id`,
+				"sqlite":   `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO NOTHING`,
+			},
+		},
+		{
+			name: "conflict_where_update",
+			stmt: func(b *Builder) Statement {
+				return b.Insert().Into("users").Columns("id", "name").Values(1, "john").
+					OnConflictColumns("id").WhereUpdate("users.locked = ?", false).DoUpdate(map[string]interface{}{"name": Ident("EXCLUDED.name")})
+			},
+			expect: map[string]string{
+				"postgres": `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name WHERE users.locked = false`,
+				"sqlite":   `INSERT INTO users(id,name) VALUES (1,'john') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name WHERE users.locked = 0`,
+			},
+			wantErr: map[string]bool{"mysql": true},
+		},
+	}
+
+	builders := []*Builder{Postgres(), MySQL(), SQLite()}
+
+	for _, tt := range cases {
+		for _, b := range builders {
+			t.Run(tt.name+"_"+b.Dialect().Name, func(t *testing.T) {
+				s, err := tt.stmt(b).String()
+				if (err != nil) != tt.wantErr[b.Dialect().Name] {
+					t.Fatalf("unexpected error building statement: %v", err)
+				}
+
+				if expected := tt.expect[b.Dialect().Name]; expected != s {
+					t.Fatalf("expected: %s, got: %s", expected, s)
+				}
+			})
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
@@ -13,19 +14,47 @@ import (
 type InsertStatement struct {
 	table        string
 	columns      []string
-	values       []Statement
+	values       []*Part
 	comment      []Statement
+	tailComments bool
 	valuesSelect *SelectStatement
 	with         Statement
 	onConflict   Statement
-	returning    []string
+	returning    []Statement
+	overriding   string
+	dialect      *Dialect
 }
 
 // Insert creates a new `INSERT` statement.
+// The table is specified with Into, e.g. `Insert().Into("users")`.
 func Insert() (s *InsertStatement) {
 	return &InsertStatement{}
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original.
+// Nested statements like the with clause and values select are shared with the original.
+func (s *InsertStatement) Clone() *InsertStatement {
+	c := *s
+	c.columns = append([]string(nil), s.columns...)
+	c.values = append([]*Part(nil), s.values...)
+	c.comment = append([]Statement(nil), s.comment...)
+	c.returning = append([]Statement(nil), s.returning...)
+
+	// the derived upsert clause must refer to the cloned columns
+	// and the conflict clause must return the cloned statement
+	switch o := s.onConflict.(type) {
+	case *upsert:
+		c.onConflict = &upsert{keys: o.keys, insert: &c}
+	case *Conflict:
+		conflict := *o
+		conflict.insert = &c
+		conflict.where = append([]Statement(nil), o.where...)
+		c.onConflict = &conflict
+	}
+
+	return &c
+}
+
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *InsertStatement) Comment(c string, values ...interface{}) *InsertStatement {
@@ -42,6 +71,21 @@ func (s *InsertStatement) Comment(c string, values ...interface{}) *InsertStatem
 	return s
 }
 
+// BlockComment adds a `/* comment */` block comment to the generated query, written on the same line
+// before the statement, e.g. for query hints or sqlcommenter style tags.
+// Any comment delimiters within the text are escaped.
+func (s *InsertStatement) BlockComment(c string) *InsertStatement {
+	s.comment = append(s.comment, newBlockComment(c))
+	return s
+}
+
+// TrailingComments places the statement comments at the end of the statement instead of the beginning,
+// as expected by some tracing and APM tools, e.g. `SELECT ... /* traceparent='...' */`.
+func (s *InsertStatement) TrailingComments() *InsertStatement {
+	s.tailComments = true
+	return s
+}
+
 // Into specifies the table on which to perform the insert
 func (s *InsertStatement) Into(table string) (st *InsertStatement) {
 	s.table = table
@@ -76,33 +120,73 @@ func (s *InsertStatement) Values(values ...interface{}) (st *InsertStatement) {
 	return s
 }
 
+// ValuesCast is like Values, but casts each value to the type at the same position in casts,
+// rendering `(?::uuid, ?::jsonb)`, e.g. for inserting string representations into typed columns.
+// A cast starting with `COLLATE` renders a collation instead, `? COLLATE "C"`.
+// Values without a corresponding or with an empty cast are not cast.
+// Casts are written as is and must not contain user provided input.
+func (s *InsertStatement) ValuesCast(casts []string, values ...interface{}) (st *InsertStatement) {
+	p := &Part{}
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("(")
+
+	for x := 0; x < len(values); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString("?")
+
+		if x < len(casts) && casts[x] != "" {
+			if strings.HasPrefix(strings.ToUpper(casts[x]), "COLLATE ") {
+				_, _ = buf.WriteString(" ")
+			} else {
+				_, _ = buf.WriteString("::")
+			}
+			_, _ = buf.WriteString(casts[x])
+		}
+		p.Values = append(p.Values, values[x])
+	}
+	_, _ = buf.WriteString(")")
+
+	p.Query = buf.String()
+	s.values = append(s.values, p)
+	return s
+}
+
 // Record add the values from the given struct for insert.
-// If no columns where specified before calling Record(), the columns will be defined by the struct fields.
+// If no columns where specified before calling Record(), the columns will be defined by the struct fields
+// in declaration order.
+// Calling Record multiple times appends a values row for each record, reusing the established columns.
 func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement) {
 	v := reflect.Indirect(reflect.ValueOf(structValue))
 
 	if v.Kind() == reflect.Struct {
 		var value []interface{}
-		m := scan.StructMap(v.Type())
+		m, columns := scan.StructMapOrdered(v.Type())
 
-		// populate columns from available record fields
+		// populate columns from available record fields in declaration order
 		// if no columns were specified up to this point
 		if len(s.columns) == 0 {
-			s.columns = make([]string, 0, len(m))
-			for key := range m {
-				s.columns = append(s.columns, key)
-			}
-
-			// ensure that the column ordering is deterministic
-			sort.Strings(s.columns)
+			s.columns = columns
 		}
 
+		// columns missing from the record or not reachable through
+		// a nil embedded struct pointer are rendered as null
 		for _, key := range s.columns {
-			if index, ok := m[key]; ok {
-				value = append(value, v.FieldByIndex(index).Interface())
-			} else {
+			index, ok := m[key]
+			if !ok {
 				value = append(value, nil)
+				continue
 			}
+
+			field, err := v.FieldByIndexErr(index)
+			if err != nil {
+				value = append(value, nil)
+				continue
+			}
+			value = append(value, field.Interface())
 		}
 		s.Values(value...)
 	}
@@ -110,12 +194,60 @@ func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement)
 	return s
 }
 
+// RecordsUnion adds a values row for each of the given structs, like Record, for inserting records with
+// differing fields. If no columns were specified before, the columns are the sorted union of the fields
+// of all records, and the columns missing from a record are rendered as null.
+func (s *InsertStatement) RecordsUnion(structValues ...interface{}) (st *InsertStatement) {
+	if len(s.columns) == 0 {
+		seen := map[string]bool{}
+		for _, structValue := range structValues {
+			v := reflect.Indirect(reflect.ValueOf(structValue))
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+
+			_, columns := scan.StructMapOrdered(v.Type())
+			for _, column := range columns {
+				if !seen[column] {
+					seen[column] = true
+					s.columns = append(s.columns, column)
+				}
+			}
+		}
+
+		// ensure that the column ordering is deterministic
+		sort.Strings(s.columns)
+	}
+
+	for _, structValue := range structValues {
+		s.Record(structValue)
+	}
+
+	return s
+}
+
 // ValuesSelect specifies a Select statement from which values will be inserted.
 func (s *InsertStatement) ValuesSelect(values *SelectStatement) (st *InsertStatement) {
 	s.valuesSelect = values
 	return s
 }
 
+// OverridingSystemValue adds a `OVERRIDING SYSTEM VALUE` clause after the columns, so the given values
+// are used for identity columns defined as `GENERATED ALWAYS`, e.g. when restoring data.
+// This is PostgreSQL specific.
+func (s *InsertStatement) OverridingSystemValue() (st *InsertStatement) {
+	s.overriding = "SYSTEM"
+	return s
+}
+
+// OverridingUserValue adds a `OVERRIDING USER VALUE` clause after the columns, so the given values
+// for identity columns defined as `GENERATED BY DEFAULT` are ignored and generated instead.
+// This is PostgreSQL specific.
+func (s *InsertStatement) OverridingUserValue() (st *InsertStatement) {
+	s.overriding = "USER"
+	return s
+}
+
 // OnConflict adds a `ON CONFLICT` clause.
 func (s *InsertStatement) OnConflict(q string, values ...interface{}) (st *InsertStatement) {
 	buf := buffer.New()
@@ -132,25 +264,79 @@ func (s *InsertStatement) OnConflict(q string, values ...interface{}) (st *Inser
 	return s
 }
 
+// UpsertOnConflict adds a `ON CONFLICT (keys) DO UPDATE SET column = EXCLUDED.column` clause
+// updating all the insert columns that are not part of the conflict target keys.
+// Dialects with DuplicateKeyUpdate, like MySQL, render `ON DUPLICATE KEY UPDATE column = VALUES(column)`.
+// The update columns are derived when the statement is built, so it can be used before or after
+// Columns or Record. When all columns are keys it renders `ON CONFLICT (keys) DO NOTHING`.
+func (s *InsertStatement) UpsertOnConflict(keys ...string) (st *InsertStatement) {
+	s.onConflict = &upsert{keys: keys, insert: s}
+	return s
+}
+
+// Upsert creates a new `INSERT` statement for the given table and struct record that updates
+// all non key columns from the record when a row with the same keys already exists,
+// e.g. `Upsert("users", user, "id")`.
+func Upsert(table string, record interface{}, keys ...string) (s *InsertStatement) {
+	return Insert().Into(table).Record(record).UpsertOnConflict(keys...)
+}
+
 // With adds a `WITH alias AS (stmt)`
 func (s *InsertStatement) With(alias string, stmt Statement) *InsertStatement {
 	s.with = &with{alias: alias, stmt: stmt}
 	return s
 }
 
-// Returning adds a `RETURNING columns` clause.
+// WithColumns adds a `WITH alias(columns...) AS (stmt)` with an explicit column list.
+func (s *InsertStatement) WithColumns(alias string, columns []string, stmt Statement) *InsertStatement {
+	s.with = &with{alias: alias, columns: columns, stmt: stmt}
+	return s
+}
+
+// Returning adds a `RETURNING columns` clause. Returning overwrites any previously returned columns or expressions.
 func (s *InsertStatement) Returning(columns ...string) *InsertStatement {
-	s.returning = columns
+	s.returning = buildReturning(columns)
+	return s
+}
+
+// ReturningExpr appends an expression to the `RETURNING` clause, e.g. `ReturningExpr("now() AS updated_at")`,
+// interpolating the given values. The expression is written as is and must not contain user provided input.
+func (s *InsertStatement) ReturningExpr(expr string, values ...interface{}) *InsertStatement {
+	s.returning = append(s.returning, &Part{Query: expr, Values: values})
 	return s
 }
 
 // Build builds the statement into the given buffer.
 func (s *InsertStatement) Build(buf Buffer) (err error) {
-	for x := 0; x < len(s.comment); x++ {
-		if err = s.comment[x].Build(buf); err != nil {
+	buf = withDialect(buf, s.dialect)
+
+	if s.table == "" {
+		return fmt.Errorf("%w: insert requires Into", ErrMissingTable)
+	}
+
+	// reject duplicate columns, which would produce an invalid statement
+	for x := 1; x < len(s.columns); x++ {
+		for y := 0; y < x; y++ {
+			if s.columns[x] == s.columns[y] {
+				return fmt.Errorf("%w: %s, columns: %s", ErrDuplicateColumn, s.columns[x], strings.Join(s.columns, ","))
+			}
+		}
+	}
+
+	// validate the values arity when both columns and values are specified
+	if s.valuesSelect == nil && len(s.columns) > 0 {
+		for x := 0; x < len(s.values); x++ {
+			if len(s.values[x].Values) != len(s.columns) {
+				return fmt.Errorf("%w: values row %d has %d values, expected %d for columns: %s",
+					ErrColumnsMismatch, x, len(s.values[x].Values), len(s.columns), strings.Join(s.columns, ","))
+			}
+		}
+	}
+
+	if !s.tailComments {
+		if err = buildComments(buf, s.comment); err != nil {
 			return err
 		}
-		_, _ = buf.WriteString("\n")
 	}
 
 	if s.with != nil {
@@ -167,6 +353,12 @@ func (s *InsertStatement) Build(buf Buffer) (err error) {
 	_, _ = buf.WriteString(strings.Join(s.columns, ","))
 	_, _ = buf.WriteString(")")
 
+	if s.overriding != "" {
+		_, _ = buf.WriteString(" OVERRIDING ")
+		_, _ = buf.WriteString(s.overriding)
+		_, _ = buf.WriteString(" VALUE")
+	}
+
 	if s.valuesSelect != nil {
 		_, _ = buf.WriteString(" (")
 		if err = s.valuesSelect.Build(buf); err != nil {
@@ -176,7 +368,11 @@ func (s *InsertStatement) Build(buf Buffer) (err error) {
 	} else {
 		_, _ = buf.WriteString(" VALUES ")
 		for x := 0; x < len(s.values); x++ {
-			if err = s.values[0].Build(buf); err != nil {
+			if x > 0 {
+				_, _ = buf.WriteString(",")
+			}
+
+			if err = s.values[x].Build(buf); err != nil {
 				return err
 			}
 		}
@@ -189,9 +385,12 @@ func (s *InsertStatement) Build(buf Buffer) (err error) {
 		}
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if err = buildList(buf, " RETURNING ", s.returning); err != nil {
+		return err
+	}
+
+	if s.tailComments {
+		return buildTrailingComments(buf, s.comment)
 	}
 
 	return nil
@@ -208,3 +407,100 @@ func (s *InsertStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// upsert represents a `ON CONFLICT (keys) DO UPDATE SET` clause derived from the insert columns.
+type upsert struct {
+	keys   []string
+	insert *InsertStatement
+}
+
+// Build builds the statement into the given buffer.
+func (s *upsert) Build(buf Buffer) (err error) {
+	if len(s.keys) == 0 {
+		return fmt.Errorf("statement: empty upsert conflict target")
+	}
+
+	if dialectOf(buf).DuplicateKeyUpdate {
+		return s.buildDuplicateKey(buf)
+	}
+
+	_, _ = buf.WriteString("ON CONFLICT (")
+	_, _ = buf.WriteString(strings.Join(s.keys, ","))
+	_, _ = buf.WriteString(")")
+
+	set := 0
+	for _, column := range s.insert.columns {
+		if s.isKey(column) {
+			continue
+		}
+
+		if set == 0 {
+			_, _ = buf.WriteString(" DO UPDATE SET ")
+		} else {
+			_, _ = buf.WriteString(", ")
+		}
+
+		_, _ = buf.WriteString(column)
+		_, _ = buf.WriteString(" = EXCLUDED.")
+		_, _ = buf.WriteString(column)
+		set++
+	}
+
+	if set == 0 {
+		_, _ = buf.WriteString(" DO NOTHING")
+	}
+
+	return nil
+}
+
+// buildDuplicateKey builds the upsert as `ON DUPLICATE KEY UPDATE column = VALUES(column), ...`.
+// When all columns are keys the first key is updated to itself, as there is no `DO NOTHING` action.
+func (s *upsert) buildDuplicateKey(buf Buffer) (err error) {
+	_, _ = buf.WriteString("ON DUPLICATE KEY UPDATE ")
+
+	set := 0
+	for _, column := range s.insert.columns {
+		if s.isKey(column) {
+			continue
+		}
+
+		if set > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+
+		_, _ = buf.WriteString(column)
+		_, _ = buf.WriteString(" = VALUES(")
+		_, _ = buf.WriteString(column)
+		_, _ = buf.WriteString(")")
+		set++
+	}
+
+	if set == 0 {
+		_, _ = buf.WriteString(s.keys[0])
+		_, _ = buf.WriteString(" = ")
+		_, _ = buf.WriteString(s.keys[0])
+	}
+
+	return nil
+}
+
+func (s *upsert) isKey(column string) bool {
+	for x := 0; x < len(s.keys); x++ {
+		if s.keys[x] == column {
+			return true
+		}
+	}
+	return false
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *upsert) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
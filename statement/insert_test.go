@@ -1,7 +1,39 @@
 package statement
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+)
+
+type nullableUser struct {
+	ID    *int64
+	Name  *string
+	Email *string
+}
+
+type auditInfo struct {
+	CreatedBy string
+	UpdatedBy string
+}
+
+type auditedUser struct {
+	ID int64
+	auditInfo
+	Name  string
+	Owner *auditInfo
+}
+
+var (
+	pointerTime     = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	pointerDuration = 90 * time.Minute
+)
+
+var (
+	nullableID   = int64(123)
+	nullableName = "john.doe"
 )
 
 var (
@@ -43,6 +75,12 @@ var (
 				Values(123, "john.doe", "john.doe@email.com", "admin").With("", Select().Columns("id").From("roles")),
 			wantErr: true,
 		},
+		{
+			name:    "block_comment",
+			expect:  `/* a * / b */ INSERT INTO users(id) VALUES (123)`,
+			stmt:    Insert().BlockComment("a */ b").Into("users").Columns("id").Values(123),
+			wantErr: false,
+		},
 		{
 			name: "comment",
 			expect: `-- request id: 12435
@@ -50,9 +88,157 @@ INSERT INTO users(id,user,email,role) VALUES (123,'john.doe','john.doe@email.com
 			stmt:    Insert().Comment("request id: ?", 12435).Into("users").Columns("id", "user", "email", "role").Values(123, "john.doe", "john.doe@email.com", "admin").Returning("id"),
 			wantErr: false,
 		},
+		{
+			name:    "default",
+			expect:  `INSERT INTO users(id,user,email,role) VALUES (DEFAULT,'john.doe','john.doe@email.com',DEFAULT)`,
+			stmt:    Insert().Into("users").Columns("id", "user", "email", "role").Values(Default, "john.doe", "john.doe@email.com", Default),
+			wantErr: false,
+		},
+		{
+			name:    "record_pointers",
+			expect:  `INSERT INTO users(id,name,email) VALUES (123,'john.doe',null)`,
+			stmt:    Insert().Into("users").Record(&nullableUser{ID: &nullableID, Name: &nullableName}),
+			wantErr: false,
+		},
+		{
+			name:   "record_time_pointers",
+			expect: `INSERT INTO events(at,elapsed,missing) VALUES ('2021-01-02T03:04:05Z','5400 seconds',null)`,
+			stmt: Insert().Into("events").Record(&struct {
+				At      *time.Time
+				Elapsed *time.Duration
+				Missing *time.Time
+			}{At: &pointerTime, Elapsed: &pointerDuration}),
+			wantErr: false,
+		},
+		{
+			name:   "record_multiple",
+			expect: `INSERT INTO users(id,name,email) VALUES (123,'john.doe',null),(321,null,null)`,
+			stmt: Insert().Into("users").Record(&nullableUser{ID: &nullableID, Name: &nullableName}).
+				Record(struct{ ID int64 }{ID: 321}),
+			wantErr: false,
+		},
+		{
+			name:    "record_embedded",
+			expect:  `INSERT INTO users(id,created_by,updated_by,name) VALUES (123,'admin','system','john.doe')`,
+			stmt:    Insert().Into("users").Record(&auditedUser{ID: 123, auditInfo: auditInfo{CreatedBy: "admin", UpdatedBy: "system"}, Name: "john.doe"}),
+			wantErr: false,
+		},
+		{
+			name:   "records_union",
+			expect: `INSERT INTO users(email,id,name,role) VALUES (null,123,'john.doe',null),(null,321,null,'admin')`,
+			stmt: Insert().Into("users").RecordsUnion(&nullableUser{ID: &nullableID, Name: &nullableName},
+				struct {
+					ID   int64
+					Role string
+				}{ID: 321, Role: "admin"}),
+			wantErr: false,
+		},
+		{
+			name:    "multiple_values",
+			expect:  `INSERT INTO users(id,name) VALUES (123,'john.doe'),(321,'jane.doe')`,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(123, "john.doe").Values(321, "jane.doe"),
+			wantErr: false,
+		},
+		{
+			name:    "overriding_system_value",
+			expect:  `INSERT INTO users(id,name) OVERRIDING SYSTEM VALUE VALUES (123,'john.doe') ON CONFLICT DO NOTHING`,
+			stmt:    Insert().Into("users").Columns("id", "name").OverridingSystemValue().Values(123, "john.doe").OnConflict("DO NOTHING"),
+			wantErr: false,
+		},
+		{
+			name:    "overriding_user_value_select",
+			expect:  `INSERT INTO users(id,name) OVERRIDING USER VALUE (SELECT id,name FROM old_users)`,
+			stmt:    Insert().Into("users").Columns("id", "name").OverridingUserValue().ValuesSelect(Select().Columns("id", "name").From("old_users")),
+			wantErr: false,
+		},
+		{
+			name:    "values_cast",
+			expect:  `INSERT INTO docs(id,owner,data,title) VALUES (123::bigint,'6ba7b810-9dad-11d1-80b4-00c04fd430c8'::uuid,'{"a":1}'::jsonb,'Über' COLLATE "C")`,
+			stmt:    Insert().Into("docs").Columns("id", "owner", "data", "title").ValuesCast([]string{"bigint", "uuid", "jsonb", `COLLATE "C"`}, 123, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", `{"a":1}`, "Über"),
+			wantErr: false,
+		},
+		{
+			name:   "values_cast_partial",
+			expect: `INSERT INTO users(id,name,role) VALUES ('6ba7b810-9dad-11d1-80b4-00c04fd430c8'::uuid,'john.doe','admin'),('6ba7b811-9dad-11d1-80b4-00c04fd430c8'::uuid,'jane.doe','owner')`,
+			stmt: Insert().Into("users").Columns("id", "name", "role").
+				ValuesCast([]string{"uuid"}, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "john.doe", "admin").
+				ValuesCast([]string{"uuid", ""}, "6ba7b811-9dad-11d1-80b4-00c04fd430c8", "jane.doe", "owner"),
+			wantErr: false,
+		},
+		{
+			name:    "on_conflict_constraint_do_nothing",
+			expect:  `INSERT INTO users(id,name) VALUES (123,'john.doe') ON CONFLICT ON CONSTRAINT users_pkey DO NOTHING RETURNING id`,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(123, "john.doe").OnConflictConstraint("users_pkey").DoNothing().Returning("id"),
+			wantErr: false,
+		},
+		{
+			name:   "on_conflict_columns_do_update",
+			expect: `INSERT INTO users_roles(user_id,role,note) VALUES (123,'admin','granted') ON CONFLICT (user_id,role) DO UPDATE SET note = EXCLUDED.note, updated_by = 'system'`,
+			stmt: Insert().Into("users_roles").Columns("user_id", "role", "note").Values(123, "admin", "granted").
+				OnConflictColumns("user_id", "role").DoUpdate(map[string]interface{}{"updated_by": "system", "note": Ident("EXCLUDED.note")}),
+			wantErr: false,
+		},
+		{
+			name:   "on_conflict_do_update_where",
+			expect: `INSERT INTO users AS t(id,name,updated_at) VALUES (123,'john.doe','2021-01-01 00:00:00') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at WHERE EXCLUDED.updated_at > t.updated_at AND t.locked = false`,
+			stmt: Insert().Into("users AS t").Columns("id", "name", "updated_at").Values(123, "john.doe", Ident("'2021-01-01 00:00:00'")).
+				OnConflictColumns("id").WhereUpdate("EXCLUDED.updated_at > t.updated_at").WhereUpdate("t.locked = ?", false).
+				DoUpdate(map[string]interface{}{"name": Ident("EXCLUDED.name"), "updated_at": Ident("EXCLUDED.updated_at")}),
+			wantErr: false,
+		},
+		{
+			name:    "on_conflict_do_nothing_where",
+			stmt:    Insert().Into("users").Columns("id").Values(123).OnConflictColumns("id").WhereUpdate("id > ?", 1).DoNothing(),
+			wantErr: true,
+		},
+		{
+			name:    "on_conflict_without_action",
+			stmt:    Insert().Into("users").Columns("id").Values(123).OnConflictColumns("id").insert,
+			wantErr: true,
+		},
+		{
+			name:    "on_conflict_empty_target",
+			stmt:    Insert().Into("users").Columns("id").Values(123).OnConflictColumns().DoNothing(),
+			wantErr: true,
+		},
+		{
+			name:    "upsert",
+			expect:  `INSERT INTO users(id,name,email) VALUES (123,'john.doe',null) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`,
+			stmt:    Upsert("users", &nullableUser{ID: &nullableID, Name: &nullableName}, "id"),
+			wantErr: false,
+		},
+		{
+			name:    "upsert_on_conflict_keys_only",
+			expect:  `INSERT INTO users_roles(role,user_id) VALUES ('admin',123) ON CONFLICT (user_id,role) DO NOTHING`,
+			stmt:    Insert().Into("users_roles").UpsertOnConflict("user_id", "role").Columns("role", "user_id").Values("admin", 123),
+			wantErr: false,
+		},
+		{
+			name:    "upsert_empty_keys",
+			stmt:    Upsert("users", &nullableUser{ID: &nullableID}),
+			wantErr: true,
+		},
 	}
 )
 
+func TestInsertColumnsMismatch(t *testing.T) {
+	stmts := []Statement{
+		Insert().Into("users").Columns("id", "user").Values(123),
+		Insert().Into("users").Columns("id", "user").Values(123, "john.doe", "john.doe@email.com"),
+	}
+
+	for _, stmt := range stmts {
+		if _, err := stmt.String(); !errors.Is(err, ErrColumnsMismatch) {
+			t.Fatalf("expected columns mismatch error, got: %v", err)
+		}
+	}
+
+	// no validation is done without columns
+	if _, err := Insert().Into("users").Values(123).String(); err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+}
+
 func TestInsert(t *testing.T) {
 	for _, tt := range insertCases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,3 +253,59 @@ func TestInsert(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertClone(t *testing.T) {
+	base := Insert().Into("users").Columns("id", "name").UpsertOnConflict("id")
+	clone := base.Clone().Columns("id", "name", "email").Values(123, "john.doe", "john.doe@email.com")
+	base.Values(321, "jane.doe")
+
+	s, err := base.String()
+	if expect := `INSERT INTO users(id,name) VALUES (321,'jane.doe') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name`; err != nil || s != expect {
+		t.Fatalf("expected: %s, got: %s, error: %v", expect, s, err)
+	}
+
+	s, err = clone.String()
+	if expect := `INSERT INTO users(id,name,email) VALUES (123,'john.doe','john.doe@email.com') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`; err != nil || s != expect {
+		t.Fatalf("expected: %s, got: %s, error: %v", expect, s, err)
+	}
+}
+
+func TestInsertDuplicateColumns(t *testing.T) {
+	_, err := Insert().Into("users").Columns("id", "name", "id").Values(123, "john.doe", 123).String()
+	if !errors.Is(err, ErrDuplicateColumn) {
+		t.Fatalf("expected duplicate column error, got: %v", err)
+	}
+
+	if expected := "statement: duplicate column: id, columns: id,name,id"; err.Error() != expected {
+		t.Fatalf("expected: %s, got: %s", expected, err)
+	}
+}
+
+func TestInsertMissingTable(t *testing.T) {
+	if _, err := Insert().Columns("id").Values(123).String(); !errors.Is(err, ErrMissingTable) {
+		t.Fatalf("expected missing table error, got: %v", err)
+	}
+}
+
+func TestRecordColumnsMatchRecord(t *testing.T) {
+	record := &auditedUser{ID: 123, auditInfo: auditInfo{CreatedBy: "admin"}, Name: "john.doe"}
+
+	columns := RecordColumns(record)
+	if len(columns) == 0 {
+		t.Fatalf("expected record columns")
+	}
+
+	stmt := Insert().Into("users").Record(record)
+	if !reflect.DeepEqual(columns, stmt.columns) {
+		t.Fatalf("expected record columns: %v, got: %v", stmt.columns, columns)
+	}
+
+	s, err := stmt.String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if prefix := "INSERT INTO users(" + strings.Join(columns, ",") + ") "; !strings.HasPrefix(s, prefix) {
+		t.Fatalf("expected statement starting with: %s, got: %s", prefix, s)
+	}
+}
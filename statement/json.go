@@ -0,0 +1,50 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON wraps a value to be rendered as its JSON encoding, e.g. for jsonb columns.
+// Strings and []byte are assumed to be already JSON encoded.
+func JSON(v interface{}) driver.Valuer {
+	return jsonValue{v: v}
+}
+
+type jsonValue struct {
+	v interface{}
+}
+
+// Value returns the JSON encoding of the value.
+func (j jsonValue) Value() (v driver.Value, err error) {
+	switch v := j.v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+
+	data, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, fmt.Errorf("statement: error encoding json value: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// buildWhereJSONText returns a `column->>'path' op value` predicate.
+func buildWhereJSONText(column, path, op string, value interface{}) (p *Part) {
+	return &Part{Query: column + "->>? " + op + " ?", Values: []interface{}{path, value}}
+}
+
+// buildWhereJSONContains returns a `column @> value::jsonb` predicate.
+func buildWhereJSONContains(column string, value interface{}) (p *Part) {
+	return &Part{Query: column + " @> ?::jsonb", Values: []interface{}{JSON(value)}}
+}
+
+// buildWhereJSONHasKey returns a `jsonb_exists(column, 'key')` predicate, equivalent to the jsonb `?` key
+// existence operator, which would be ambiguous with `?` placeholders in parameterized statements.
+func buildWhereJSONHasKey(column, key string) (p *Part) {
+	return &Part{Query: "jsonb_exists(" + column + ", ?)", Values: []interface{}{key}}
+}
@@ -0,0 +1,46 @@
+package statement
+
+import (
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	cases := []struct {
+		name    string
+		expect  string
+		stmt    Statement
+		wantErr bool
+	}{
+		{
+			name:   "struct",
+			expect: `UPDATE users SET settings = '{"theme":"dark","notify":true}' WHERE id = 1`,
+			stmt: Update().Table("users").Set("settings", JSON(struct {
+				Theme  string `json:"theme"`
+				Notify bool   `json:"notify"`
+			}{"dark", true})).Where("id = ?", 1),
+		},
+		{
+			name:   "encoded",
+			expect: `SELECT id FROM users WHERE settings @> '{"it''s":"quoted"}'::jsonb`,
+			stmt:   Select().Columns("id").From("users").WhereJSONContains("settings", `{"it's":"quoted"}`),
+		},
+		{
+			name:    "invalid",
+			stmt:    Select().Columns("id").From("users").WhereJSONContains("settings", make(chan int)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.stmt.String()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error building statement: %v", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+		})
+	}
+}
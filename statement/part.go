@@ -10,7 +10,30 @@ import (
 // Ident type is handled as an user provided identifier as is in the resulting query
 type Ident string
 
-// Part is a query fragment that satisfies the statement.Statement interface
+// Default is rendered as the `DEFAULT` keyword, so a column takes its default value,
+// e.g. `Insert().Into("users").Columns("id", "name").Values(statement.Default, "john")`.
+const Default Ident = "DEFAULT"
+
+// Raw is a query fragment that satisfies the statement.Statement interface and is written as is,
+// without placeholder processing or value interpolation. It can be used for fragments containing a
+// literal `?` or already built queries, e.g. `Select().Columns(statement.Raw("data ? 'key'"))`.
+// Raw is never escaped and must not contain user provided input.
+type Raw string
+
+// String returns the raw query.
+func (r Raw) String() (q string, err error) {
+	return string(r), nil
+}
+
+// Build writes the raw query into the given buffer.
+func (r Raw) Build(buf Buffer) (err error) {
+	_, _ = buf.WriteString(string(r))
+	return nil
+}
+
+// Part is a query fragment that satisfies the statement.Statement interface.
+// Each `?` in the query is a placeholder for the next value, while an escaped `??` is written
+// as a literal `?`, e.g. for the jsonb existence operator: `Part{Query: "data ?? ?", Values: []interface{}{"key"}}`.
 type Part struct {
 	Query  string
 	Values []interface{}
@@ -34,40 +57,170 @@ func (p *Part) Build(buf Buffer) (err error) {
 }
 
 func (p *Part) build(buf Buffer, keyword bool) (err error) {
-	if strings.Count(p.Query, "?") != len(p.Values) {
+	if countPlaceholders(p.Query) != len(p.Values) {
 		return fmt.Errorf("%w: %s, %#v", ErrInvalidArgNumber, p.Query, p.Values)
 	}
 
 	valueIdx := 0
 	query := p.Query
 	for {
-		idx := strings.Index(query, "?")
+		idx := strings.IndexByte(query, '?')
 		if idx == -1 {
 			_, _ = buf.WriteString(query)
 			break
 		}
 
+		// an escaped `??` is written as a literal `?`
+		if idx+1 < len(query) && query[idx+1] == '?' {
+			_, _ = buf.WriteString(query[:idx+1])
+			query = query[idx+2:]
+			continue
+		}
+
 		_, _ = buf.WriteString(query[:idx])
 		query = query[idx+1:]
 
+		if valueIdx%checkInterval == 0 {
+			if err = buildErr(buf); err != nil {
+				return err
+			}
+		}
+
 		arg := p.Values[valueIdx]
 		valueIdx++
 
-		switch arg := arg.(type) {
-		case Statement:
-			_, _ = buf.WriteString("(")
-			err = arg.Build(buf)
-			_, _ = buf.WriteString(")")
-		case Ident:
-			_, _ = buf.WriteString(string(arg))
-		default:
-			err = writeValue(buf, arg, keyword)
+		if err = writeArg(buf, arg, keyword); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// countPlaceholders returns the number of `?` placeholders in the query, not counting escaped `??`.
+func countPlaceholders(query string) (n int) {
+	for x := 0; x < len(query); x++ {
+		if query[x] != '?' {
+			continue
+		}
+
+		if x+1 < len(query) && query[x+1] == '?' {
+			x++
+			continue
+		}
+		n++
+	}
+
+	return n
+}
+
+// NamedPart is a query fragment with `:name` placeholders that satisfies the statement.Statement interface.
+// Each placeholder is replaced by the value of the same name, in the order placeholders appear in the query,
+// so a name can be used multiple times, e.g. `NamedPart{Query: "a = :x OR b = :x", Values: map[string]interface{}{"x": 1}}`.
+// Names start with a letter or underscore followed by letters, digits or underscores. Single quoted literals,
+// like `'10:30'`, and `::` type casts are written as is. A placeholder without a value returns ErrMissingNamedArg.
+type NamedPart struct {
+	Query  string
+	Values map[string]interface{}
+}
+
+// String builds the part and returns the resulting query.
+func (p *NamedPart) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = p.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Build builds the part into the given buffer.
+func (p *NamedPart) Build(buf Buffer) (err error) {
+	valueIdx := 0
+	query := p.Query
+	for {
+		idx := strings.IndexAny(query, ":'")
+		if idx == -1 {
+			_, _ = buf.WriteString(query)
+			break
+		}
+
+		// quoted literals are written as is, a `''` escape closes and reopens the literal
+		if query[idx] == '\'' {
+			end := strings.IndexByte(query[idx+1:], '\'')
+			if end == -1 {
+				_, _ = buf.WriteString(query)
+				break
+			}
+
+			_, _ = buf.WriteString(query[:idx+end+2])
+			query = query[idx+end+2:]
+			continue
+		}
+
+		// `::` type casts are written as is
+		if idx+1 < len(query) && query[idx+1] == ':' {
+			_, _ = buf.WriteString(query[:idx+2])
+			query = query[idx+2:]
+			continue
 		}
 
-		if err != nil {
+		end := idx + 1
+		for end < len(query) && isNameChar(query[end], end == idx+1) {
+			end++
+		}
+
+		// not a placeholder, like a `:` not followed by a name
+		if end == idx+1 {
+			_, _ = buf.WriteString(query[:idx+1])
+			query = query[idx+1:]
+			continue
+		}
+
+		name := query[idx+1 : end]
+		arg, ok := p.Values[name]
+		if !ok {
+			return fmt.Errorf("%w: %s, query: %s", ErrMissingNamedArg, name, p.Query)
+		}
+
+		_, _ = buf.WriteString(query[:idx])
+		query = query[end:]
+
+		if valueIdx%checkInterval == 0 {
+			if err = buildErr(buf); err != nil {
+				return err
+			}
+		}
+		valueIdx++
+
+		if err = writeArg(buf, arg, false); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// isNameChar reports whether c can be part of a named placeholder, digits are not allowed as the first char.
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// writeArg writes an interpolated argument into the buffer. Statements are enclosed in parentheses,
+// identifiers are written as is and any other values are written according to their types.
+func writeArg(buf Buffer, arg interface{}, keyword bool) (err error) {
+	switch arg := arg.(type) {
+	case Statement:
+		_, _ = buf.WriteString("(")
+		err = arg.Build(buf)
+		_, _ = buf.WriteString(")")
+	case Ident:
+		_, _ = buf.WriteString(string(arg))
+	default:
+		err = writeValue(buf, arg, keyword)
+	}
+
+	return err
+}
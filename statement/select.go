@@ -25,28 +25,78 @@ var (
 type SelectStatement struct {
 	limitCount     int64
 	offsetCount    int64
+	fetchCount     int64
+	fetchWithTies  bool
 	order          string
 	isDistinct     bool
 	isForUpdate    bool
 	isSkipLocked   bool
+	isNoCache      bool
 	tableStatement bool
 	with           Statement
 	union          Statement
 	table          Statement
+	tableSample    *tableSample
 	columns        []interface{}
 	groupBy        []string
 	orderBy        []string
 	comment        []Statement
+	tailComments   bool
 	join           []Statement
 	where          []Statement
 	having         []Statement
+	dialect        *Dialect
 }
 
 // Select creates a new `SELECT` statement.
+// Columns are specified with Columns or Column, e.g. `Select().Columns("id", "name")`.
+// The `FROM` clause is omitted when no table is specified, e.g. `Select().Columns("now()")`.
 func Select() *SelectStatement {
 	return &SelectStatement{}
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing a base query to be built once and specialized for each use.
+// Nested statements like subqueries, with and union clauses are shared with the original.
+func (s *SelectStatement) Clone() *SelectStatement {
+	c := *s
+	c.columns = append([]interface{}(nil), s.columns...)
+	c.groupBy = append([]string(nil), s.groupBy...)
+	c.orderBy = append([]string(nil), s.orderBy...)
+	c.comment = append([]Statement(nil), s.comment...)
+	c.where = append([]Statement(nil), s.where...)
+	c.having = append([]Statement(nil), s.having...)
+
+	// join conditions can still be added to the original, so they are copied
+	c.join = make([]Statement, len(s.join))
+	for x := 0; x < len(s.join); x++ {
+		c.join[x] = s.join[x]
+		if j, ok := s.join[x].(*joinOn); ok {
+			jc := *j
+			jc.cond = append([]*Part(nil), j.cond...)
+			c.join[x] = &jc
+		}
+	}
+
+	return &c
+}
+
+// Exists returns a new `SELECT EXISTS (stmt)` statement for checking if the statement matches any row.
+// The `ORDER BY` clause is irrelevant for existence and is stripped from the wrapped statement, which is
+// left unchanged, unless required by `FETCH FIRST ... WITH TIES`. The `LIMIT`, `OFFSET` and `FETCH FIRST`
+// clauses are kept, so that with an offset it checks if the statement matches more than offset rows.
+func (s *SelectStatement) Exists() *SelectStatement {
+	c := s.Clone()
+	if !c.fetchWithTies {
+		c.orderBy = nil
+		c.order = ""
+	}
+
+	e := Select().Column("EXISTS ?", c)
+	e.isNoCache = s.isNoCache
+	return e
+}
+
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *SelectStatement) Comment(c string, values ...interface{}) *SelectStatement {
@@ -63,22 +113,60 @@ func (s *SelectStatement) Comment(c string, values ...interface{}) *SelectStatem
 	return s
 }
 
-// Columns set the `SELECT` columns. Columns overwrites any previously set columns for this statement.
+// BlockComment adds a `/* comment */` block comment to the generated query, written on the same line
+// before the statement, e.g. for query hints or sqlcommenter style tags.
+// Any comment delimiters within the text are escaped.
+func (s *SelectStatement) BlockComment(c string) *SelectStatement {
+	s.comment = append(s.comment, newBlockComment(c))
+	return s
+}
+
+// TrailingComments places the statement comments at the end of the statement instead of the beginning,
+// as expected by some tracing and APM tools, e.g. `SELECT ... /* traceparent='...' */`.
+func (s *SelectStatement) TrailingComments() *SelectStatement {
+	s.tailComments = true
+	return s
+}
+
+// Columns set the `SELECT` columns, which can be column names or expressions as strings,
+// or any Statement like a Part or Raw, which are built in place.
+// Columns overwrites any previously set columns for this statement.
 func (s *SelectStatement) Columns(columns ...interface{}) *SelectStatement {
 	s.columns = columns
 	return s
 }
 
+// ColumnsFor sets the `SELECT` columns to the columns the given struct maps to, as returned by RecordColumns,
+// keeping the selected columns in sync with the type the rows are scanned into, e.g. `ColumnsFor(&User{})`.
+// ColumnsFor overwrites any previously set columns for this statement.
+func (s *SelectStatement) ColumnsFor(structValue interface{}) *SelectStatement {
+	columns := RecordColumns(structValue)
+	s.columns = make([]interface{}, len(columns))
+	for x := 0; x < len(columns); x++ {
+		s.columns[x] = columns[x]
+	}
+	return s
+}
+
 // Column append the given column to the `SELECT`. Column appends to the existing columns already specified.
-// Used for more ellaborate column specification.
+// Used for more ellaborate column specification with interpolated values, like a correlated scalar subquery,
+// e.g. `Column("(SELECT count(*) FROM orders WHERE user_id = u.id AND status = ?) AS paid", "paid")`.
+// Statement values, like a *SelectStatement, are enclosed in parentheses, e.g. `Column("? AS total", subquery)`.
+// Column values are interpolated before the values of the following clauses.
 func (s *SelectStatement) Column(q string, values ...interface{}) *SelectStatement {
 	s.columns = append(s.columns, &Part{Query: q, Values: values})
 	return s
 }
 
 // From sets the table name or *Select statement for the `FROM` clause.
+// An aliased *ValuesStatement and Raw are used as is.
 func (s *SelectStatement) From(table interface{}) *SelectStatement {
 	switch table := table.(type) {
+	case Raw:
+		s.table = table
+	case *ValuesStatement:
+		s.tableStatement = table.alias == ""
+		s.table = table
 	case Statement:
 		s.tableStatement = true
 		s.table = table
@@ -89,6 +177,14 @@ func (s *SelectStatement) From(table interface{}) *SelectStatement {
 	return s
 }
 
+// TableSample adds a `TABLESAMPLE method (percent)` clause after the table name for querying
+// a random sample of the table, e.g. `TableSample("BERNOULLI", 10)`.
+// It is only valid with a table name set in From and percent must be between 0 and 100.
+func (s *SelectStatement) TableSample(method string, percent float64) *SelectStatement {
+	s.tableSample = &tableSample{method: strings.ToUpper(method), percent: percent}
+	return s
+}
+
 // Join adds a `JOIN ...` clause.
 func (s *SelectStatement) Join(join Join, table, cond string, values ...interface{}) *SelectStatement {
 	buf := buffer.New()
@@ -108,6 +204,36 @@ func (s *SelectStatement) Join(join Join, table, cond string, values ...interfac
 	return s
 }
 
+// JoinSub adds a `JOIN (subquery) AS alias ON ...` clause joining a derived table.
+// The subquery values are interpolated before the condition values.
+func (s *SelectStatement) JoinSub(join Join, sub Statement, alias, cond string, values ...interface{}) *SelectStatement {
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString(string(join))
+	_, _ = buf.WriteString(" ? AS ")
+	_, _ = buf.WriteString(alias)
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(cond)
+
+	p := &Part{}
+	p.Values = append([]interface{}{sub}, values...)
+	p.Query = buf.String()
+
+	s.join = append(s.join, p)
+	return s
+}
+
+// JoinOn adds a `JOIN table ON conditions` clause, with the conditions specified with
+// JoinCondition.On, And and Or, which interpolate their values in order, e.g.
+// `JoinOn(InnerJoin, "orders AS o").On("o.user_id = u.id").And("o.status = ?", "paid")`.
+// The returned JoinCondition embeds the statement, so it can be further built after the conditions.
+func (s *SelectStatement) JoinOn(join Join, table string) *JoinCondition {
+	j := &joinOn{join: join, table: table}
+	s.join = append(s.join, j)
+	return &JoinCondition{SelectStatement: s, join: j}
+}
+
 // JoinInner adds a `INNER JOIN` clause.
 func (s *SelectStatement) JoinInner(table, cond string, values ...interface{}) *SelectStatement {
 	return s.Join(InnerJoin, table, cond, values...)
@@ -134,15 +260,119 @@ func (s *SelectStatement) Where(q string, values ...interface{}) *SelectStatemen
 	return s
 }
 
+// WherePart adds a `WHERE` clause from an already built statement part, multiple calls to WherePart
+// and Where are `ANDed` together.
+func (s *SelectStatement) WherePart(p Statement) *SelectStatement {
+	s.where = append(s.where, p)
+	return s
+}
+
+// WhereNamed adds a `WHERE` clause with `:name` placeholders replaced by the values of the same name,
+// allowing a name to be used multiple times, e.g. `WhereNamed("a = :x OR b = :x", map[string]interface{}{"x": 1})`.
+// Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereNamed(q string, values map[string]interface{}) *SelectStatement {
+	s.where = append(s.where, &NamedPart{Query: q, Values: values})
+	return s
+}
+
+// WhereAll adds a `WHERE` clause for each of the given conditions, `ANDing` them together and with
+// any other where clauses, which is useful for filters assembled at runtime, e.g. from a []Statement.
+// Conditions are written as is, so conditions containing `OR` should be enclosed in parentheses.
+func (s *SelectStatement) WhereAll(conditions ...Statement) *SelectStatement {
+	s.where = append(s.where, conditions...)
+	return s
+}
+
 // Having adds a `HAVING` clause, multiple calls to Having are `ANDed` together.
 func (s *SelectStatement) Having(q string, values ...interface{}) *SelectStatement {
 	s.having = append(s.having, &Part{Query: q, Values: values})
 	return s
 }
 
+// HavingPart adds a `HAVING` clause from an already built statement part, multiple calls to HavingPart
+// and Having are `ANDed` together.
+func (s *SelectStatement) HavingPart(p Statement) *SelectStatement {
+	s.having = append(s.having, p)
+	return s
+}
+
 // WhereIn adds a `WHERE IN (values)` clause, multiple calls to WhereIn are `ANDed` together.
 func (s *SelectStatement) WhereIn(column string, values ...interface{}) *SelectStatement {
-	s.where = append(s.where, buildWhereIn(column, values...))
+	s.where = append(s.where, buildWhereIn(column, false, values...))
+	return s
+}
+
+// WhereNotIn adds a `WHERE NOT IN (values)` clause, multiple calls to WhereNotIn are `ANDed` together.
+func (s *SelectStatement) WhereNotIn(column string, values ...interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereIn(column, true, values...))
+	return s
+}
+
+// WhereAny adds a `WHERE value = ANY(column)` clause for membership in an array column,
+// multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereAny(value interface{}, column string) *SelectStatement {
+	s.where = append(s.where, buildWhereAny(value, column))
+	return s
+}
+
+// WhereArrayContains adds a `WHERE column @> ARRAY[values...]` clause for array columns containing
+// all the given values, a single slice argument is expanded into the array values.
+// Building fails with ErrEmptyArray when there are no values.
+// Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereArrayContains(column string, values ...interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereArrayContains(column, values...))
+	return s
+}
+
+// WhereJSONText adds a `WHERE column->>'path' op value` clause comparing the text value of a json field,
+// e.g. `WhereJSONText("data", "status", "=", "active")`. Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereJSONText(column, path, op string, value interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereJSONText(column, path, op, value))
+	return s
+}
+
+// WhereJSONContains adds a `WHERE column @> value::jsonb` clause, where value is encoded as JSON
+// unless it is a string or []byte. Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereJSONContains(column string, value interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereJSONContains(column, value))
+	return s
+}
+
+// WhereJSONHasKey adds a `WHERE jsonb_exists(column, 'key')` clause, the function form of the jsonb `?` key
+// existence operator, which is not mistaken for a placeholder by drivers using `?` placeholders.
+// Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereJSONHasKey(column, key string) *SelectStatement {
+	s.where = append(s.where, buildWhereJSONHasKey(column, key))
+	return s
+}
+
+// WhereDistinctFrom adds a NULL safe `WHERE column IS DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereDistinctFrom(column string, value interface{}) *SelectStatement {
+	s.where = append(s.where, &Part{Query: column + " IS DISTINCT FROM ?", Values: []interface{}{value}})
+	return s
+}
+
+// WhereNotDistinctFrom adds a NULL safe `WHERE column IS NOT DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereNotDistinctFrom(column string, value interface{}) *SelectStatement {
+	s.where = append(s.where, &Part{Query: column + " IS NOT DISTINCT FROM ?", Values: []interface{}{value}})
+	return s
+}
+
+// WhereRowLess adds a `WHERE (columns...) < (values...)` row value comparison clause, as used for descending
+// keyset pagination, e.g. `WhereRowLess([]string{"created_at", "id"}, lastCreatedAt, lastID)`.
+// Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereRowLess(columns []string, values ...interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereRow(columns, "<", values...))
+	return s
+}
+
+// WhereRowGreater adds a `WHERE (columns...) > (values...)` row value comparison clause, as used for ascending
+// keyset pagination, e.g. `WhereRowGreater([]string{"created_at", "id"}, lastCreatedAt, lastID)`.
+// Multiple where clauses are `ANDed` together.
+func (s *SelectStatement) WhereRowGreater(columns []string, values ...interface{}) *SelectStatement {
+	s.where = append(s.where, buildWhereRow(columns, ">", values...))
 	return s
 }
 
@@ -166,13 +396,53 @@ func (s *SelectStatement) OrderDesc(columns ...string) *SelectStatement {
 	return s
 }
 
+// OrderAscNullsFirst adds a `ORDER BY column ASC NULLS FIRST, ...` clause for each of the given columns.
+func (s *SelectStatement) OrderAscNullsFirst(columns ...string) *SelectStatement {
+	return s.OrderBy(orderItems(columns, " ASC NULLS FIRST")...)
+}
+
+// OrderAscNullsLast adds a `ORDER BY column ASC NULLS LAST, ...` clause for each of the given columns.
+func (s *SelectStatement) OrderAscNullsLast(columns ...string) *SelectStatement {
+	return s.OrderBy(orderItems(columns, " ASC NULLS LAST")...)
+}
+
+// OrderDescNullsFirst adds a `ORDER BY column DESC NULLS FIRST, ...` clause for each of the given columns.
+func (s *SelectStatement) OrderDescNullsFirst(columns ...string) *SelectStatement {
+	return s.OrderBy(orderItems(columns, " DESC NULLS FIRST")...)
+}
+
+// OrderDescNullsLast adds a `ORDER BY column DESC NULLS LAST, ...` clause for each of the given columns.
+func (s *SelectStatement) OrderDescNullsLast(columns ...string) *SelectStatement {
+	return s.OrderBy(orderItems(columns, " DESC NULLS LAST")...)
+}
+
+// OrderBy adds a `ORDER BY items` clause where each item is a verbatim `ORDER BY` element,
+// allowing expressions, collations and per item directions,
+// e.g. `OrderBy(`name COLLATE "de_DE" ASC`, "created_at DESC")`.
+// OrderBy overwrites any ordering previously set with OrderBy, OrderAsc or OrderDesc.
+func (s *SelectStatement) OrderBy(items ...string) *SelectStatement {
+	s.orderBy = items
+	s.order = ""
+	return s
+}
+
 // Limit adds a `LIMIT n` clause.
 func (s *SelectStatement) Limit(n int64) *SelectStatement {
 	s.limitCount = n
 	return s
 }
 
-// Offset adds a `OFFSET n` clause, only if LIMIT is also set.
+// FetchFirst adds a standard `FETCH FIRST n ROWS ONLY` clause, or `FETCH FIRST n ROWS WITH TIES`
+// when withTies is true to also include the rows that tie with the last one according to the
+// `ORDER BY` clause, which is then required. When set, the Offset is rendered as `OFFSET n ROWS`.
+// FetchFirst and Limit are mutually exclusive.
+func (s *SelectStatement) FetchFirst(n int64, withTies bool) *SelectStatement {
+	s.fetchCount = n
+	s.fetchWithTies = withTies
+	return s
+}
+
+// Offset adds a `OFFSET n` clause, only if LIMIT or FETCH FIRST is also set.
 func (s *SelectStatement) Offset(n int64) *SelectStatement {
 	s.offsetCount = n
 	return s
@@ -196,6 +466,19 @@ func (s *SelectStatement) SkipLocked() *SelectStatement {
 	return s
 }
 
+// NoCache marks the statement as non-cacheable, for queries with non-deterministic results like
+// `now()` or `random()`. Non-cacheable statements always hit the database, even when
+// executed with a caching query method.
+func (s *SelectStatement) NoCache() *SelectStatement {
+	s.isNoCache = true
+	return s
+}
+
+// Cacheable returns whether the statement results can be cached.
+func (s *SelectStatement) Cacheable() bool {
+	return !s.isNoCache
+}
+
 // With adds a `WITH alias AS (stmt)`
 func (s *SelectStatement) With(alias string, stmt Statement) *SelectStatement {
 	s.with = &with{recursive: false, alias: alias, stmt: stmt}
@@ -208,13 +491,28 @@ func (s *SelectStatement) WithRecursive(alias string, stmt Statement) *SelectSta
 	return s
 }
 
+// WithColumns adds a `WITH alias(columns...) AS (stmt)` with an explicit column list.
+func (s *SelectStatement) WithColumns(alias string, columns []string, stmt Statement) *SelectStatement {
+	s.with = &with{recursive: false, alias: alias, columns: columns, stmt: stmt}
+	return s
+}
+
+// WithRecursiveColumns adds a `WITH RECURSIVE alias(columns...) AS (stmt)` with an explicit column list.
+func (s *SelectStatement) WithRecursiveColumns(alias string, columns []string, stmt Statement) *SelectStatement {
+	s.with = &with{recursive: true, alias: alias, columns: columns, stmt: stmt}
+	return s
+}
+
 // Union adds a `UNION` clause.
+// When a union is present each branch is enclosed in parentheses and the `ORDER BY`, `LIMIT`
+// and `OFFSET` clauses of this statement apply to the whole union, being rendered after the
+// final branch: `(SELECT ...) UNION (SELECT ...) ORDER BY ... LIMIT ...`.
 func (s *SelectStatement) Union(stmt Statement) *SelectStatement {
 	s.union = &union{stmt: stmt}
 	return s
 }
 
-// UnionAll adds a `UNION ALL` clause.
+// UnionAll adds a `UNION ALL` clause, with the same semantics as Union.
 func (s *SelectStatement) UnionAll(stmt Statement) *SelectStatement {
 	s.union = &union{all: true, stmt: stmt}
 	return s
@@ -222,11 +520,31 @@ func (s *SelectStatement) UnionAll(stmt Statement) *SelectStatement {
 
 // Build builds the statement into the given buffer.
 func (s *SelectStatement) Build(buf Buffer) (err error) {
-	for x := 0; x < len(s.comment); x++ {
-		if err = s.comment[x].Build(buf); err != nil {
+	buf = withDialect(buf, s.dialect)
+
+	if s.fetchCount > 0 && s.limitCount > 0 {
+		return ErrLimitFetchFirst
+	}
+
+	if s.fetchWithTies && len(s.orderBy) == 0 {
+		return ErrFetchWithTiesOrder
+	}
+
+	if s.tableSample != nil {
+		if err = s.validateTableSample(); err != nil {
+			return err
+		}
+	}
+
+	if s.limitCount < 0 || s.offsetCount < 0 || s.fetchCount < 0 {
+		return fmt.Errorf("statement: limit, offset and fetch first must not be negative, limit: %d, offset: %d, fetch first: %d",
+			s.limitCount, s.offsetCount, s.fetchCount)
+	}
+
+	if !s.tailComments {
+		if err = buildComments(buf, s.comment); err != nil {
 			return err
 		}
-		_, _ = buf.WriteString("\n")
 	}
 
 	if s.with != nil {
@@ -236,6 +554,10 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 		_, _ = buf.WriteString(" ")
 	}
 
+	if s.union != nil {
+		_, _ = buf.WriteString("(")
+	}
+
 	_, _ = buf.WriteString("SELECT ")
 
 	if s.isDistinct {
@@ -272,6 +594,13 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 		if err != nil {
 			return err
 		}
+
+		if s.tableSample != nil {
+			_, _ = buf.WriteString(" ")
+			if err = s.tableSample.Build(buf); err != nil {
+				return err
+			}
+		}
 	}
 
 	for x := 0; x < len(s.join); x++ {
@@ -304,15 +633,41 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 
 	}
 
+	if s.union != nil {
+		_, _ = buf.WriteString(") ")
+		if err = s.union.Build(buf); err != nil {
+			return err
+		}
+	}
+
 	if len(s.orderBy) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
 		_, _ = buf.WriteString(strings.Join(s.orderBy, `,`))
-		_, _ = buf.WriteString(" ")
-		_, _ = buf.WriteString(s.order)
+		if s.order != "" {
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(s.order)
+		}
 	}
 
 	if s.limitCount > 0 {
-		_, _ = buf.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", s.limitCount, s.offsetCount))
+		_, _ = buf.WriteString(" LIMIT ")
+		_ = writeValue(buf, s.limitCount, false)
+		_, _ = buf.WriteString(" OFFSET ")
+		_ = writeValue(buf, s.offsetCount, false)
+	}
+
+	if s.fetchCount > 0 {
+		_, _ = buf.WriteString(" OFFSET ")
+		_ = writeValue(buf, s.offsetCount, false)
+		_, _ = buf.WriteString(" ROWS FETCH FIRST ")
+		_ = writeValue(buf, s.fetchCount, false)
+		_, _ = buf.WriteString(" ROWS")
+		switch s.fetchWithTies {
+		case true:
+			_, _ = buf.WriteString(" WITH TIES")
+		case false:
+			_, _ = buf.WriteString(" ONLY")
+		}
 	}
 
 	if s.isForUpdate {
@@ -323,11 +678,8 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 		_, _ = buf.WriteString(" SKIP LOCKED")
 	}
 
-	if s.union != nil {
-		_, _ = buf.WriteString(" ")
-		if err = s.union.Build(buf); err != nil {
-			return err
-		}
+	if s.tailComments {
+		return buildTrailingComments(buf, s.comment)
 	}
 
 	return nil
@@ -344,3 +696,118 @@ func (s *SelectStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// orderItems appends the given direction and nulls ordering to each column.
+func orderItems(columns []string, order string) (items []string) {
+	items = make([]string, 0, len(columns))
+	for x := 0; x < len(columns); x++ {
+		items = append(items, columns[x]+order)
+	}
+	return items
+}
+
+// tableSample represents a `TABLESAMPLE method (percent)` clause.
+type tableSample struct {
+	method  string
+	percent float64
+}
+
+// Build builds the clause into the given buffer.
+func (t *tableSample) Build(buf Buffer) (err error) {
+	_, _ = buf.WriteString("TABLESAMPLE ")
+	_, _ = buf.WriteString(t.method)
+	_, _ = buf.WriteString(" (")
+	if err = writeValue(buf, t.percent, false); err != nil {
+		return err
+	}
+	_, _ = buf.WriteString(")")
+	return nil
+}
+
+// validateTableSample checks that the table sample is applied to a table name
+// with a valid sampling method and percentage.
+func (s *SelectStatement) validateTableSample() (err error) {
+	if _, ok := s.table.(*Part); !ok || s.tableStatement {
+		return fmt.Errorf("statement: table sample requires a table name")
+	}
+
+	method := s.tableSample.method
+	if method == "" || strings.TrimLeft(method, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_") != "" {
+		return fmt.Errorf("statement: invalid table sample method: %s", method)
+	}
+
+	if percent := s.tableSample.percent; percent < 0 || percent > 100 {
+		return fmt.Errorf("statement: table sample percent must be between 0 and 100, got: %v", percent)
+	}
+
+	return nil
+}
+
+// JoinCondition accumulates the `ON` conditions of a join added with SelectStatement.JoinOn.
+type JoinCondition struct {
+	*SelectStatement
+	join *joinOn
+}
+
+// On adds the first join condition, or a condition `ANDed` with the previous ones.
+func (c *JoinCondition) On(cond string, values ...interface{}) *JoinCondition {
+	return c.And(cond, values...)
+}
+
+// And adds a join condition `ANDed` with the previous ones.
+func (c *JoinCondition) And(cond string, values ...interface{}) *JoinCondition {
+	c.join.add(" AND ", cond, values)
+	return c
+}
+
+// Or adds a join condition `ORed` with the previous ones.
+func (c *JoinCondition) Or(cond string, values ...interface{}) *JoinCondition {
+	c.join.add(" OR ", cond, values)
+	return c
+}
+
+// joinOn represents a `JOIN table ON conditions` clause.
+type joinOn struct {
+	join  Join
+	table string
+	cond  []*Part
+}
+
+func (j *joinOn) add(op, cond string, values []interface{}) {
+	if len(j.cond) == 0 {
+		op = ""
+	}
+	j.cond = append(j.cond, &Part{Query: op + cond, Values: values})
+}
+
+// Build builds the clause into the given buffer.
+func (j *joinOn) Build(buf Buffer) (err error) {
+	if len(j.cond) == 0 {
+		return fmt.Errorf("statement: join on %s requires a condition", j.table)
+	}
+
+	_, _ = buf.WriteString(string(j.join))
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(j.table)
+	_, _ = buf.WriteString(" ON ")
+
+	for x := 0; x < len(j.cond); x++ {
+		if err = j.cond[x].Build(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String builds the clause and returns the resulting query string.
+func (j *joinOn) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = j.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -3,6 +3,8 @@ package statement
 import "testing"
 
 var (
+	activeUsers = &Part{Query: "active = ? AND deleted_at IS NULL", Values: []interface{}{true}}
+
 	selectCases = []struct {
 		name    string
 		expect  string
@@ -47,9 +49,273 @@ var (
 				WhereIn("role", "admin", "owner"),
 			wantErr: false,
 		},
+		{
+			name:    "where_any",
+			expect:  `SELECT id FROM posts WHERE 'go' = ANY(tags) AND tags @> ARRAY['sql','db']`,
+			stmt:    Select().Columns("id").From("posts").WhereAny("go", "tags").WhereArrayContains("tags", []string{"sql", "db"}),
+			wantErr: false,
+		},
+		{
+			name:    "where_array_contains_nil",
+			stmt:    Select().Columns("id").From("posts").WhereArrayContains("tags", nil),
+			wantErr: true,
+		},
+		{
+			name:    "where_array_contains_empty",
+			stmt:    Select().Columns("id").From("posts").WhereArrayContains("tags", []string{}),
+			wantErr: true,
+		},
+		{
+			name:    "where_array_contains_no_values",
+			stmt:    Select().Columns("id").From("posts").WhereArrayContains("tags"),
+			wantErr: true,
+		},
+		{
+			name:    "where_json",
+			expect:  `SELECT id FROM events WHERE data->>'status' = 'active' AND data @> '{"tags":["sql"]}'::jsonb AND jsonb_exists(data, 'owner')`,
+			stmt:    Select().Columns("id").From("events").WhereJSONText("data", "status", "=", "active").WhereJSONContains("data", map[string][]string{"tags": {"sql"}}).WhereJSONHasKey("data", "owner"),
+			wantErr: false,
+		},
+		{
+			name:    "where_escaped_placeholder",
+			expect:  `SELECT id FROM events WHERE data ? 'owner' AND data ?| array['a','b'] AND data ?& array['c'] AND id = 1`,
+			stmt:    Select().Columns("id").From("events").Where("data ?? ?", "owner").Where("data ??| array[?,?]", "a", "b").Where("data ??& array[?]", "c").Where("id = ?", 1),
+			wantErr: false,
+		},
+		{
+			name:    "where_escaped_placeholder_mismatch",
+			stmt:    Select().Columns("id").From("events").Where("data ?? 'owner' AND id = ?"),
+			wantErr: true,
+		},
+		{
+			name:    "where_named",
+			expect:  `SELECT id FROM events WHERE (owner = 'john' OR assignee = 'john') AND created_at::date > '2021-01-01'`,
+			stmt:    Select().Columns("id").From("events").WhereNamed("(owner = :user OR assignee = :user) AND created_at::date > :day", map[string]interface{}{"user": "john", "day": "2021-01-01"}),
+			wantErr: false,
+		},
+		{
+			name:    "where_named_missing",
+			stmt:    Select().Columns("id").From("events").WhereNamed("owner = :user AND day = :day", map[string]interface{}{"user": "john"}),
+			wantErr: true,
+		},
+		{
+			name:   "record_columns",
+			expect: `SELECT user_id,name,email FROM users`,
+			stmt: Select().ColumnsFor(&struct {
+				ID    int64 `db:"user_id"`
+				Name  string
+				Email string
+			}{}).From("users"),
+			wantErr: false,
+		},
+		{
+			name:    "where_row_less",
+			expect:  `SELECT id FROM events WHERE (created_at, id) < ('2021-01-01', 100) ORDER BY created_at DESC,id DESC LIMIT 10 OFFSET 0`,
+			stmt:    Select().Columns("id").From("events").WhereRowLess([]string{"created_at", "id"}, "2021-01-01", 100).OrderBy("created_at DESC", "id DESC").Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "where_row_greater",
+			expect:  `SELECT id FROM events WHERE tenant = 'acme' AND (created_at, id) > ('2021-01-01', 100) ORDER BY created_at,id LIMIT 10 OFFSET 0`,
+			stmt:    Select().Columns("id").From("events").Where("tenant = ?", "acme").WhereRowGreater([]string{"created_at", "id"}, "2021-01-01", 100).OrderBy("created_at", "id").Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "where_row_values_mismatch",
+			stmt:    Select().Columns("id").From("events").WhereRowLess([]string{"created_at", "id"}, "2021-01-01"),
+			wantErr: true,
+		},
+		{
+			name:    "where_distinct_from",
+			expect:  `SELECT id FROM users WHERE email IS DISTINCT FROM null AND role IS NOT DISTINCT FROM 'admin'`,
+			stmt:    Select().Columns("id").From("users").WhereDistinctFrom("email", nil).WhereNotDistinctFrom("role", "admin"),
+			wantErr: false,
+		},
+		{
+			name:    "fetch_first_with_ties",
+			expect:  `SELECT name,score FROM players ORDER BY score DESC OFFSET 0 ROWS FETCH FIRST 3 ROWS WITH TIES`,
+			stmt:    Select().Columns("name", "score").From("players").OrderDesc("score").FetchFirst(3, true),
+			wantErr: false,
+		},
+		{
+			name:    "fetch_first_offset",
+			expect:  `SELECT name FROM players OFFSET 10 ROWS FETCH FIRST 5 ROWS ONLY`,
+			stmt:    Select().Columns("name").From("players").FetchFirst(5, false).Offset(10),
+			wantErr: false,
+		},
+		{
+			name:    "fetch_first_with_ties_without_order",
+			stmt:    Select().Columns("name").From("players").FetchFirst(3, true),
+			wantErr: true,
+		},
+		{
+			name:    "fetch_first_and_limit",
+			stmt:    Select().Columns("name").From("players").OrderDesc("score").FetchFirst(3, false).Limit(3),
+			wantErr: true,
+		},
+		{
+			name:   "join_sub",
+			expect: `SELECT u.name,o.total FROM users AS u INNER JOIN (SELECT user_id,SUM(amount) AS total FROM orders WHERE status = 'paid' GROUP BY user_id) AS o ON o.user_id = u.id AND o.total > 100 WHERE u.active = true`,
+			stmt: Select().Columns("u.name", "o.total").From("users AS u").
+				JoinSub(InnerJoin, Select().Columns("user_id", "SUM(amount) AS total").From("orders").
+					Where("status = ?", "paid").GroupBy("user_id"), "o", "o.user_id = u.id AND o.total > ?", 100).
+				Where("u.active = ?", true),
+			wantErr: false,
+		},
+		{
+			name:    "exists",
+			expect:  `SELECT EXISTS (SELECT id FROM users WHERE role = 'admin' LIMIT 10 OFFSET 0)`,
+			stmt:    Select().Columns("id").From("users").Where("role = ?", "admin").OrderAsc("id").Limit(10).Exists(),
+			wantErr: false,
+		},
+		{
+			name:    "exists_offset",
+			expect:  `SELECT EXISTS (SELECT id FROM users WHERE role = 'admin' LIMIT 1 OFFSET 10)`,
+			stmt:    Select().Columns("id").From("users").Where("role = ?", "admin").OrderDesc("id").Limit(1).Offset(10).Exists(),
+			wantErr: false,
+		},
+		{
+			name:    "exists_fetch_with_ties",
+			expect:  `SELECT EXISTS (SELECT id FROM users ORDER BY score DESC OFFSET 0 ROWS FETCH FIRST 5 ROWS WITH TIES)`,
+			stmt:    Select().Columns("id").From("users").OrderBy("score DESC").FetchFirst(5, true).Exists(),
+			wantErr: false,
+		},
+		{
+			name:    "without_from_constant",
+			expect:  `SELECT 1`,
+			stmt:    Select().Columns("1"),
+			wantErr: false,
+		},
+		{
+			name:    "without_from_function",
+			expect:  `SELECT now()`,
+			stmt:    Select().Columns("now()"),
+			wantErr: false,
+		},
+		{
+			name:    "without_from_sequence",
+			expect:  `SELECT nextval('users_id_seq')`,
+			stmt:    Select().Column("nextval(?)", "users_id_seq"),
+			wantErr: false,
+		},
+		{
+			name:    "order_by_collate",
+			expect:  `SELECT id,name FROM users ORDER BY name COLLATE "de_DE" ASC,lower(email) DESC,id LIMIT 10 OFFSET 0`,
+			stmt:    Select().Columns("id", "name").From("users").OrderBy(`name COLLATE "de_DE" ASC`, "lower(email) DESC", "id").Limit(10),
+			wantErr: false,
+		},
+		{
+			name:    "order_desc_nulls_last",
+			expect:  `SELECT id,name FROM users ORDER BY last_login DESC NULLS LAST,id DESC NULLS LAST`,
+			stmt:    Select().Columns("id", "name").From("users").OrderDescNullsLast("last_login", "id"),
+			wantErr: false,
+		},
+		{
+			name:    "order_desc_nulls_first",
+			expect:  `SELECT id,name FROM users ORDER BY last_login DESC NULLS FIRST`,
+			stmt:    Select().Columns("id", "name").From("users").OrderDescNullsFirst("last_login"),
+			wantErr: false,
+		},
+		{
+			name:    "order_asc_nulls",
+			expect:  `SELECT id,name FROM users ORDER BY deleted_at ASC NULLS FIRST`,
+			stmt:    Select().Columns("id", "name").From("users").OrderAscNullsLast("name").OrderAscNullsFirst("deleted_at"),
+			wantErr: false,
+		},
+		{
+			name: "block_comment",
+			expect: `-- request id: 1
+/* application='api',route='/users' */ /* drop * /; DELETE FROM users; / * */ SELECT id FROM users`,
+			stmt: Select().Comment("request id: ?", 1).BlockComment("application='api',route='/users'").
+				BlockComment("drop */; DELETE FROM users; /*").Columns("id").From("users"),
+			wantErr: false,
+		},
+		{
+			name:    "table_sample_bernoulli",
+			expect:  `SELECT id,total FROM orders TABLESAMPLE BERNOULLI (10) WHERE status = 'paid'`,
+			stmt:    Select().Columns("id", "total").From("orders").TableSample("bernoulli", 10).Where("status = ?", "paid"),
+			wantErr: false,
+		},
+		{
+			name:    "table_sample_system",
+			expect:  `SELECT count(*) FROM orders AS o TABLESAMPLE SYSTEM (0.5)`,
+			stmt:    Select().Columns("count(*)").From("orders AS o").TableSample("SYSTEM", 0.5),
+			wantErr: false,
+		},
+		{
+			name:    "table_sample_subquery",
+			stmt:    Select().Columns("id").From(Select().Columns("id").From("orders")).TableSample("SYSTEM", 10),
+			wantErr: true,
+		},
+		{
+			name:    "table_sample_invalid_method",
+			stmt:    Select().Columns("id").From("orders").TableSample("SYSTEM (1); DROP TABLE orders; --", 10),
+			wantErr: true,
+		},
+		{
+			name:    "table_sample_invalid_percent",
+			stmt:    Select().Columns("id").From("orders").TableSample("SYSTEM", 110),
+			wantErr: true,
+		},
+		{
+			name: "with_leading_comment",
+			expect: `-- request id: 1
+/* route='/offices' */ WITH uk AS (SELECT id FROM offices WHERE country = 'uk') SELECT id FROM uk`,
+			stmt: Select().Comment("request id: ?", 1).BlockComment("route='/offices'").
+				With("uk", Select().Columns("id").From("offices").Where("country = ?", "uk")).
+				Columns("id").From("uk"),
+			wantErr: false,
+		},
+		{
+			name: "with_trailing_comment",
+			expect: `WITH uk AS (SELECT id FROM offices WHERE country = 'uk') SELECT id FROM uk LIMIT 10 OFFSET 0 /* route='/offices' */
+-- request id: 1
+`,
+			stmt: Select().BlockComment("route='/offices'").Comment("request id: ?", 1).TrailingComments().
+				With("uk", Select().Columns("id").From("offices").Where("country = ?", "uk")).
+				Columns("id").From("uk").Limit(10),
+			wantErr: false,
+		},
+		{
+			name: "trailing_comment_subquery",
+			expect: `SELECT EXISTS (SELECT id FROM users WHERE id = 1
+-- request id: 1
+)`,
+			stmt:    Select().Comment("request id: ?", 1).TrailingComments().Columns("id").From("users").Where("id = ?", 1).Exists(),
+			wantErr: false,
+		},
+		{
+			name:   "scalar_subquery_column",
+			expect: `SELECT u.id,(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = 'paid') AS order_count,(SELECT max(total) FROM orders WHERE user_id = u.id) AS max_total FROM users AS u WHERE u.id = 123`,
+			stmt: Select().Columns("u.id").
+				Column("(SELECT count(*) FROM orders AS o WHERE o.user_id = u.id AND o.status = ?) AS order_count", "paid").
+				Column("? AS max_total", Select().Columns("max(total)").From("orders").Where("user_id = u.id")).
+				From("users AS u").Where("u.id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:   "join_on",
+			expect: `SELECT u.id,o.total FROM users AS u INNER JOIN orders AS o ON o.user_id = u.id AND o.status = 'paid' AND o.total > 100 LEFT OUTER JOIN coupons AS c ON c.order_id = o.id OR c.user_id = u.id WHERE u.active = true`,
+			stmt: Select().Columns("u.id", "o.total").From("users AS u").
+				JoinOn(InnerJoin, "orders AS o").On("o.user_id = u.id").And("o.status = ?", "paid").And("o.total > ?", 100).
+				JoinOn(LeftOuterJoin, "coupons AS c").On("c.order_id = o.id").Or("c.user_id = u.id").
+				Where("u.active = ?", true),
+			wantErr: false,
+		},
+		{
+			name:    "join_on_without_condition",
+			stmt:    Select().Columns("u.id").From("users AS u").JoinOn(InnerJoin, "orders AS o").Where("u.active = ?", true),
+			wantErr: true,
+		},
+		{
+			name:    "raw",
+			expect:  `SELECT id,data ? 'admin' AS is_admin FROM users, jsonb_each(data) WHERE data ?| array['a','b'] AND id = 1`,
+			stmt:    Select().Columns("id", Raw("data ? 'admin' AS is_admin")).From(Raw("users, jsonb_each(data)")).WherePart(Raw("data ?| array['a','b']")).Where("id = ?", 1),
+			wantErr: false,
+		},
 		{
 			name:   "with_recursive_union",
-			expect: `WITH RECURSIVE included_parts AS (SELECT sub_part,part,quantity FROM parts WHERE part = 'our_product' UNION ALL SELECT p.sub_part,p.part,p.quantity FROM included_parts AS pr INNER JOIN parts AS p ON p.part = pr.sub_part) SELECT sub_part,SUM(quantity) as total_quantity FROM included_parts GROUP BY sub_part`,
+			expect: `WITH RECURSIVE included_parts AS ((SELECT sub_part,part,quantity FROM parts WHERE part = 'our_product') UNION ALL (SELECT p.sub_part,p.part,p.quantity FROM included_parts AS pr INNER JOIN parts AS p ON p.part = pr.sub_part)) SELECT sub_part,SUM(quantity) as total_quantity FROM included_parts GROUP BY sub_part`,
 			stmt: Select().WithRecursive(
 				"included_parts",
 				Select().Columns("sub_part", "part", "quantity").
@@ -63,6 +329,14 @@ var (
 				From("included_parts").GroupBy("sub_part"),
 			wantErr: false,
 		},
+		{
+			name:   "with_recursive_columns",
+			expect: `WITH RECURSIVE t(n) AS ((SELECT 1) UNION ALL (SELECT n+1 FROM t WHERE n < 100)) SELECT sum(n) FROM t`,
+			stmt: Select().WithRecursiveColumns("t", []string{"n"},
+				Select().Columns("1").UnionAll(Select().Columns("n+1").From("t").Where("n < ?", 100)),
+			).Columns("sum(n)").From("t"),
+			wantErr: false,
+		},
 		{
 			name: "comment",
 			expect: `-- request id: 12435
@@ -81,6 +355,47 @@ WITH select_offices AS (SELECT country,city,address,postal_code FROM offices WHE
 				GroupBy("id", "name"),
 			wantErr: false,
 		},
+		{
+			name:   "union_order_limit",
+			expect: `(SELECT id,email FROM users WHERE role = 'admin') UNION (SELECT id,email FROM old_users WHERE role = 'admin') ORDER BY email ASC LIMIT 10 OFFSET 0`,
+			stmt: Select().Columns("id", "email").From("users").Where("role = ?", "admin").
+				Union(Select().Columns("id", "email").From("old_users").Where("role = ?", "admin")).
+				OrderAsc("email").Limit(10),
+			wantErr: false,
+		},
+		{
+			name:   "union_all_branch_order",
+			expect: `(SELECT id FROM users) UNION ALL (SELECT id FROM old_users ORDER BY created_at DESC LIMIT 5 OFFSET 0) ORDER BY id ASC`,
+			stmt: Select().Columns("id").From("users").
+				UnionAll(Select().Columns("id").From("old_users").OrderDesc("created_at").Limit(5)).
+				OrderAsc("id"),
+			wantErr: false,
+		},
+		{
+			name:    "where_not_in",
+			expect:  `SELECT id FROM users WHERE role NOT IN ('admin','owner') AND id NOT IN (1,2,3)`,
+			stmt:    Select().Columns("id").From("users").WhereNotIn("role", "admin", "owner").WhereNotIn("id", []int{1, 2, 3}),
+			wantErr: false,
+		},
+		{
+			name:    "where_not_in_subquery",
+			expect:  `SELECT id FROM users WHERE role NOT IN (SELECT name FROM roles WHERE expired = true)`,
+			stmt:    Select().Columns("id").From("users").WhereNotIn("role", Select().Columns("name").From("roles").Where("expired = ?", true)),
+			wantErr: false,
+		},
+		{
+			name:    "where_part",
+			expect:  `SELECT id,email FROM users WHERE active = true AND deleted_at IS NULL AND role = 'admin'`,
+			stmt:    Select().Columns("id", "email").From("users").WherePart(activeUsers).Where("role = ?", "admin"),
+			wantErr: false,
+		},
+		{
+			name:   "where_part_shared",
+			expect: `SELECT role,count(*) FROM users WHERE active = true AND deleted_at IS NULL GROUP BY role HAVING count(*) > 10`,
+			stmt: Select().Columns("role", "count(*)").From("users").WherePart(activeUsers).GroupBy("role").
+				HavingPart(&Part{Query: "count(*) > ?", Values: []interface{}{10}}),
+			wantErr: false,
+		},
 	}
 )
 
@@ -98,3 +413,86 @@ func TestSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectWhereAll(t *testing.T) {
+	type filter struct {
+		column string
+		value  interface{}
+	}
+
+	filters := []filter{{"active", true}, {"country", nil}, {"role", "admin"}, {"age", 18}}
+
+	// assemble the conditions from the filters that are set
+	var conditions []Statement
+	for _, f := range filters {
+		if f.value == nil {
+			continue
+		}
+		conditions = append(conditions, &Part{Query: f.column + " = ?", Values: []interface{}{f.value}})
+	}
+
+	s, err := Select().Columns("id").From("users").Where("deleted_at IS NULL").WhereAll(conditions...).String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT id FROM users WHERE deleted_at IS NULL AND active = true AND role = 'admin' AND age = 18`
+	if s != expect {
+		t.Fatalf("expected: %s, got: %s", expect, s)
+	}
+}
+
+func TestSelectClone(t *testing.T) {
+	base := Select().Columns("id", "name").From("users").Where("active = ?", true)
+
+	admins := base.Clone().Where("role = ?", "admin").OrderAsc("name")
+	owners := base.Clone().Where("role = ?", "owner")
+
+	cases := []struct {
+		stmt   Statement
+		expect string
+	}{
+		{base, `SELECT id,name FROM users WHERE active = true`},
+		{admins, `SELECT id,name FROM users WHERE active = true AND role = 'admin' ORDER BY name ASC`},
+		{owners, `SELECT id,name FROM users WHERE active = true AND role = 'owner'`},
+	}
+
+	for _, c := range cases {
+		s, err := c.stmt.String()
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		if s != c.expect {
+			t.Fatalf("expected: %s, got: %s", c.expect, s)
+		}
+	}
+}
+
+func TestSelectCloneJoinOn(t *testing.T) {
+	cond := Select().Columns("u.id").From("users AS u").JoinOn(InnerJoin, "orders AS o").On("o.user_id = u.id")
+	clone := cond.Clone()
+
+	// conditions added to the original join after cloning are not seen by the clone
+	cond.And("o.status = ?", "paid")
+	clone.Where("u.active = ?", true)
+
+	cases := []struct {
+		stmt   Statement
+		expect string
+	}{
+		{cond.SelectStatement, `SELECT u.id FROM users AS u INNER JOIN orders AS o ON o.user_id = u.id AND o.status = 'paid'`},
+		{clone, `SELECT u.id FROM users AS u INNER JOIN orders AS o ON o.user_id = u.id WHERE u.active = true`},
+	}
+
+	for _, c := range cases {
+		s, err := c.stmt.String()
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		if s != c.expect {
+			t.Fatalf("expected: %s, got: %s", c.expect, s)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package statement
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/brunotm/norm/internal/buffer"
 	"github.com/brunotm/norm/internal/scan"
@@ -14,6 +15,27 @@ var (
 
 	// ErrInvalidArgNumber will be returned when there is a mismatch between placeholders and values for interpolation.
 	ErrInvalidArgNumber = fmt.Errorf("statement: invalid number of arguments")
+
+	// ErrColumnsMismatch will be returned when the number of values does not match the number of columns.
+	ErrColumnsMismatch = fmt.Errorf("statement: number of values does not match the number of columns")
+
+	// ErrDuplicateColumn will be returned when a column is specified more than once for insert.
+	ErrDuplicateColumn = fmt.Errorf("statement: duplicate column")
+
+	// ErrLimitFetchFirst will be returned when both LIMIT and FETCH FIRST are specified.
+	ErrLimitFetchFirst = fmt.Errorf("statement: limit and fetch first are mutually exclusive")
+
+	// ErrMissingTable will be returned when an insert, update or delete statement has no target table.
+	ErrMissingTable = fmt.Errorf("statement: missing table")
+
+	// ErrMissingNamedArg will be returned when a named placeholder has no corresponding value.
+	ErrMissingNamedArg = fmt.Errorf("statement: missing named argument")
+
+	// ErrEmptyArray will be returned when an array predicate has no values.
+	ErrEmptyArray = fmt.Errorf("statement: empty array values")
+
+	// ErrFetchWithTiesOrder will be returned when FETCH FIRST WITH TIES is specified without ORDER BY.
+	ErrFetchWithTiesOrder = fmt.Errorf("statement: fetch first with ties requires order by")
 )
 
 // Buffer represents the write buffer for building statements.
@@ -29,7 +51,18 @@ type Statement interface {
 	String() (q string, err error)
 }
 
-// buildWhereIn builds a `WHERE` clause.
+// Cacheable is implemented by statements which can opt out of query result caching.
+type Cacheable interface {
+	Cacheable() bool
+}
+
+// Transactional is implemented by statements which may not be able to run within a transaction,
+// like `CREATE INDEX CONCURRENTLY`.
+type Transactional interface {
+	Transactional() bool
+}
+
+// buildWhere builds a `WHERE` clause.
 func buildWhere(buf Buffer, where []Statement) (err error) {
 	for x := 0; x < len(where); x++ {
 		if x == 0 {
@@ -46,6 +79,142 @@ func buildWhere(buf Buffer, where []Statement) (err error) {
 	return nil
 }
 
+// buildOrderLimit writes the `ORDER BY items LIMIT n` clauses of delete and update statements.
+func buildOrderLimit(buf Buffer, orderBy []string, limit int64) (err error) {
+	if len(orderBy) > 0 {
+		_, _ = buf.WriteString(" ORDER BY ")
+		_, _ = buf.WriteString(strings.Join(orderBy, ","))
+	}
+
+	if limit > 0 {
+		_, _ = buf.WriteString(" LIMIT ")
+		return writeValue(buf, limit, false)
+	}
+
+	return nil
+}
+
+// blockComment is a `/* comment */` written inline before the statement.
+type blockComment string
+
+// newBlockComment creates a blockComment escaping any comment delimiters within the text,
+// so it can't terminate the comment early or open a nested one.
+func newBlockComment(c string) blockComment {
+	c = strings.ReplaceAll(c, "*/", "* /")
+	c = strings.ReplaceAll(c, "/*", "/ *")
+	return blockComment(c)
+}
+
+// Build builds the comment into the given buffer.
+func (c blockComment) Build(buf Buffer) (err error) {
+	_, _ = buf.WriteString("/* ")
+	_, _ = buf.WriteString(string(c))
+	_, _ = buf.WriteString(" */")
+	return nil
+}
+
+// String returns the comment.
+func (c blockComment) String() (q string, err error) {
+	return "/* " + string(c) + " */", nil
+}
+
+// buildComments builds the statement comments, line comments followed by a new line
+// and block comments by a space.
+// Comments values are always interpolated, as placeholders are not allowed in comments.
+func buildComments(buf Buffer, comments []Statement) (err error) {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	b := newBuildBuffer(buf)
+	if b.skipComments {
+		return nil
+	}
+	b.args = nil
+
+	for x := 0; x < len(comments); x++ {
+		if err = comments[x].Build(b); err != nil {
+			return err
+		}
+
+		if _, ok := comments[x].(blockComment); ok {
+			_, _ = b.WriteString(" ")
+			continue
+		}
+		_, _ = b.WriteString("\n")
+	}
+
+	return nil
+}
+
+// buildTrailingComments builds the statement comments after the statement, with block comments
+// preceded by a space and line comments on their own line terminated by a new line,
+// so the statement can still be enclosed in parentheses.
+func buildTrailingComments(buf Buffer, comments []Statement) (err error) {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	b := newBuildBuffer(buf)
+	if b.skipComments {
+		return nil
+	}
+	b.args = nil
+
+	for x := 0; x < len(comments); x++ {
+		if _, ok := comments[x].(blockComment); ok {
+			_, _ = b.WriteString(" ")
+			if err = comments[x].Build(b); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, _ = b.WriteString("\n")
+		if err = comments[x].Build(b); err != nil {
+			return err
+		}
+		_, _ = b.WriteString("\n")
+	}
+
+	return nil
+}
+
+// buildReturning returns the given returning columns as statements written as is.
+func buildReturning(columns []string) (returning []Statement) {
+	returning = make([]Statement, len(columns))
+	for x := 0; x < len(columns); x++ {
+		returning[x] = Raw(columns[x])
+	}
+	return returning
+}
+
+// buildList builds the given statements as a comma separated list preceded by the given clause,
+// only if the list is not empty.
+func buildList(buf Buffer, clause string, list []Statement) (err error) {
+	for x := 0; x < len(list); x++ {
+		if x == 0 {
+			_, _ = buf.WriteString(clause)
+		} else {
+			_, _ = buf.WriteString(",")
+		}
+
+		if err = list[x].Build(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordColumns returns the column names the given struct maps to in field declaration order, honoring db tags,
+// as used to scan rows into the struct and by InsertStatement.Record and UpdateStatement.Record. Nested struct
+// fields are represented by the columns of their own fields. It allows keeping column lists in sync with the
+// scanned type, e.g. `Insert().Into("users").Columns(RecordColumns(&User{})...)` or `Select().ColumnsFor(&User{})`.
+func RecordColumns(structValue interface{}) []string {
+	return scan.Columns(structValue)
+}
+
 // InterfaceSlice converts any slice to a []interface{}
 func InterfaceSlice(slice interface{}) []interface{} {
 	s := reflect.ValueOf(slice)
@@ -67,35 +236,142 @@ func InterfaceSlice(slice interface{}) []interface{} {
 	return ret
 }
 
-// buildWhereIn builds a `WHERE IN (values)` clause.
-func buildWhereIn(column string, values ...interface{}) (p *Part) {
-	buf := buffer.New()
-	defer buf.Release()
+// whereIn represents a `WHERE IN (values)` or `WHERE NOT IN (values)` clause.
+type whereIn struct {
+	not    bool
+	column string
+	values []interface{}
+}
+
+// buildWhereIn builds a `WHERE IN (values)` or `WHERE NOT IN (values)` clause.
+// A single slice argument is expanded into the list of values.
+func buildWhereIn(column string, not bool, values ...interface{}) (s *whereIn) {
+	if len(values) == 1 && scan.IsSlice(values[0]) {
+		values = InterfaceSlice(values[0])
+	}
 
-	p = &Part{}
+	return &whereIn{not: not, column: column, values: values}
+}
+
+// buildWhereAny returns a `value = ANY(column)` predicate.
+func buildWhereAny(value interface{}, column string) (p *Part) {
+	return &Part{Query: "? = ANY(" + column + ")", Values: []interface{}{value}}
+}
+
+// arrayContains represents a `column @> ARRAY[values...]` predicate.
+type arrayContains struct {
+	column string
+	values []interface{}
+}
 
+// buildWhereArrayContains returns a `column @> ARRAY[values...]` predicate.
+// A single slice argument is expanded into the list of values.
+func buildWhereArrayContains(column string, values ...interface{}) (s *arrayContains) {
 	if len(values) == 1 && scan.IsSlice(values[0]) {
 		values = InterfaceSlice(values[0])
 	}
 
-	_, _ = buf.WriteString(column)
-	_, _ = buf.WriteString(" IN (")
-	for x := 0; x < len(values); x++ {
+	return &arrayContains{column: column, values: values}
+}
+
+// Build builds the statement into the given buffer.
+func (s *arrayContains) Build(buf Buffer) (err error) {
+	// an untyped empty array is rejected by the database
+	if len(s.values) == 0 || (len(s.values) == 1 && s.values[0] == nil) {
+		return fmt.Errorf("%w: %s", ErrEmptyArray, s.column)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(s.values)), ",")
+	return (&Part{Query: s.column + " @> ARRAY[" + placeholders + "]", Values: s.values}).Build(buf)
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *arrayContains) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// qualifiedKey returns the key column qualified with the table name or alias, e.g. `users.id` or `u.id`,
+// so that it is not ambiguous with the columns of other tables in the statement.
+// Keys already qualified are returned as is.
+func qualifiedKey(table, key string) string {
+	if strings.Contains(key, ".") {
+		return key
+	}
+
+	if idx := strings.LastIndex(strings.ToUpper(table), " AS "); idx != -1 {
+		table = table[idx+len(" AS "):]
+	}
+
+	return strings.TrimSpace(table) + "." + key
+}
+
+// buildWhereRow returns a `(columns...) op (values...)` row value comparison predicate.
+// The number of values must match the number of columns.
+func buildWhereRow(columns []string, op string, values ...interface{}) (p *Part) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	return &Part{Query: "(" + strings.Join(columns, ", ") + ") " + op + " (" + placeholders + ")", Values: values}
+}
+
+// Build builds the statement into the given buffer.
+func (s *whereIn) Build(buf Buffer) (err error) {
+	_, _ = buf.WriteString(s.column)
+	if s.not {
+		_, _ = buf.WriteString(" NOT")
+	}
+	_, _ = buf.WriteString(" IN ")
+
+	// a single subquery is already enclosed in parentheses when built
+	if len(s.values) == 1 {
+		if _, ok := s.values[0].(Statement); ok {
+			return writeArg(buf, s.values[0], false)
+		}
+	}
+
+	_, _ = buf.WriteString("(")
+	for x := 0; x < len(s.values); x++ {
+		if x%checkInterval == 0 {
+			if err = buildErr(buf); err != nil {
+				return err
+			}
+		}
+
 		if x > 0 {
 			_, _ = buf.WriteString(",")
 		}
-		_, _ = buf.WriteString("?")
-		p.Values = append(p.Values, values[x])
+
+		if err = writeArg(buf, s.values[x], false); err != nil {
+			return err
+		}
 	}
 	_, _ = buf.WriteString(")")
-	p.Query = buf.String()
-	return p
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *whereIn) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
 }
 
 // with represents a `WITH` clause.
 type with struct {
 	recursive bool
 	alias     string
+	columns   []string
 	stmt      Statement
 }
 
@@ -115,6 +391,11 @@ func (s *with) Build(buf Buffer) (err error) {
 
 	_, _ = buf.WriteString(w)
 	_, _ = buf.WriteString(s.alias)
+	if len(s.columns) > 0 {
+		_, _ = buf.WriteString("(")
+		_, _ = buf.WriteString(strings.Join(s.columns, ","))
+		_, _ = buf.WriteString(")")
+	}
 	_, _ = buf.WriteString(" AS (")
 	if err = s.stmt.Build(buf); err != nil {
 		return err
@@ -145,12 +426,17 @@ type union struct {
 func (s *union) Build(buf Buffer) (err error) {
 	switch s.all {
 	case false:
-		_, _ = buf.WriteString("UNION ")
+		_, _ = buf.WriteString("UNION (")
 	case true:
-		_, _ = buf.WriteString("UNION ALL ")
+		_, _ = buf.WriteString("UNION ALL (")
+	}
+
+	if err = s.stmt.Build(buf); err != nil {
+		return err
 	}
 
-	return s.stmt.Build(buf)
+	_, _ = buf.WriteString(")")
+	return nil
 }
 
 // String builds the statement and returns the resulting query string.
@@ -1,25 +1,47 @@
 package statement
 
 import (
+	"fmt"
+	"reflect"
 	"sort"
-	"strings"
 
 	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/internal/scan"
 )
 
 // UpdateStatement statement.
 type UpdateStatement struct {
-	table     string
-	with      Statement
-	values    map[string]interface{}
-	where     []Statement
-	comment   []Statement
-	returning []string
+	table        string
+	with         Statement
+	values       map[string]interface{}
+	where        []Statement
+	comment      []Statement
+	tailComments bool
+	returning    []Statement
+	orderBy      []string
+	limitCount   int64
+	limitKey     string
+	dialect      *Dialect
 }
 
 // Update creates a new update statement
 func Update() (s *UpdateStatement) {
-	return &UpdateStatement{values: make(map[string]interface{})}
+	return &UpdateStatement{values: make(map[string]interface{}), limitKey: "id"}
+}
+
+// Clone returns a copy of the statement that can be modified without affecting the original.
+// Nested statements like the with clause are shared with the original.
+func (s *UpdateStatement) Clone() *UpdateStatement {
+	c := *s
+	c.values = make(map[string]interface{}, len(s.values))
+	for k, v := range s.values {
+		c.values[k] = v
+	}
+	c.where = append([]Statement(nil), s.where...)
+	c.comment = append([]Statement(nil), s.comment...)
+	c.returning = append([]Statement(nil), s.returning...)
+	c.orderBy = append([]string(nil), s.orderBy...)
+	return &c
 }
 
 // Comment adds a SQL comment to the generated query.
@@ -38,12 +60,34 @@ func (s *UpdateStatement) Comment(c string, values ...interface{}) *UpdateStatem
 	return s
 }
 
+// BlockComment adds a `/* comment */` block comment to the generated query, written on the same line
+// before the statement, e.g. for query hints or sqlcommenter style tags.
+// Any comment delimiters within the text are escaped.
+func (s *UpdateStatement) BlockComment(c string) *UpdateStatement {
+	s.comment = append(s.comment, newBlockComment(c))
+	return s
+}
+
+// TrailingComments places the statement comments at the end of the statement instead of the beginning,
+// as expected by some tracing and APM tools, e.g. `SELECT ... /* traceparent='...' */`.
+func (s *UpdateStatement) TrailingComments() *UpdateStatement {
+	s.tailComments = true
+	return s
+}
+
 // Table specifies the table for update.
 func (s *UpdateStatement) Table(table string) *UpdateStatement {
 	s.table = table
 	return s
 }
 
+// TableAs specifies the table for update with an alias, `UPDATE table AS alias`,
+// which can be referenced by the other clauses of the statement.
+func (s *UpdateStatement) TableAs(table, alias string) *UpdateStatement {
+	s.table = table + " AS " + alias
+	return s
+}
+
 // Set adds a `SET column = value` clause, multiple calls to set append
 // additional updates `SET column = value, column = value`
 func (s *UpdateStatement) Set(column string, value interface{}) *UpdateStatement {
@@ -59,37 +103,199 @@ func (s *UpdateStatement) SetMap(m map[string]interface{}) *UpdateStatement {
 	return s
 }
 
+// Record adds a `SET column = value` for each of the given struct fields, mapped to columns as in
+// InsertStatement.Record, with nested and embedded structs flattened into the columns of their fields.
+// Fields not reachable through a nil embedded struct pointer are skipped.
+// The SET columns are always written in sorted order.
+func (s *UpdateStatement) Record(structValue interface{}) *UpdateStatement {
+	return s.record(structValue, false)
+}
+
+// RecordNonZero is like Record, but skips the struct fields holding zero values,
+// allowing partial updates from a struct.
+func (s *UpdateStatement) RecordNonZero(structValue interface{}) *UpdateStatement {
+	return s.record(structValue, true)
+}
+
+func (s *UpdateStatement) record(structValue interface{}, skipZero bool) *UpdateStatement {
+	v := reflect.Indirect(reflect.ValueOf(structValue))
+	if v.Kind() != reflect.Struct {
+		return s
+	}
+
+	m, columns := scan.StructMapOrdered(v.Type())
+	for _, column := range columns {
+		field, err := v.FieldByIndexErr(m[column])
+		if err != nil || (skipZero && field.IsZero()) {
+			continue
+		}
+		s.values[column] = field.Interface()
+	}
+
+	return s
+}
+
 // With adds a `WITH alias AS (stmt)` clause.
 func (s *UpdateStatement) With(alias string, stmt Statement) *UpdateStatement {
 	s.with = &with{alias: alias, stmt: stmt}
 	return s
 }
 
+// WithColumns adds a `WITH alias(columns...) AS (stmt)` with an explicit column list.
+func (s *UpdateStatement) WithColumns(alias string, columns []string, stmt Statement) *UpdateStatement {
+	s.with = &with{alias: alias, columns: columns, stmt: stmt}
+	return s
+}
+
 // Where adds a `WHERE` clause, multiple calls to Where are `ANDed` together.
 func (s *UpdateStatement) Where(q string, values ...interface{}) *UpdateStatement {
 	s.where = append(s.where, &Part{Query: q, Values: values})
 	return s
 }
 
+// WherePart adds a `WHERE` clause from an already built statement part, multiple calls to WherePart
+// and Where are `ANDed` together.
+func (s *UpdateStatement) WherePart(p Statement) *UpdateStatement {
+	s.where = append(s.where, p)
+	return s
+}
+
+// WhereNamed adds a `WHERE` clause with `:name` placeholders replaced by the values of the same name,
+// allowing a name to be used multiple times, e.g. `WhereNamed("a = :x OR b = :x", map[string]interface{}{"x": 1})`.
+// Multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereNamed(q string, values map[string]interface{}) *UpdateStatement {
+	s.where = append(s.where, &NamedPart{Query: q, Values: values})
+	return s
+}
+
+// WhereAll adds a `WHERE` clause for each of the given conditions, `ANDing` them together and with
+// any other where clauses, which is useful for filters assembled at runtime, e.g. from a []Statement.
+// Conditions are written as is, so conditions containing `OR` should be enclosed in parentheses.
+func (s *UpdateStatement) WhereAll(conditions ...Statement) *UpdateStatement {
+	s.where = append(s.where, conditions...)
+	return s
+}
+
 // WhereIn adds a `WHERE IN (values)` clause, multiple calls to WhereIn are `ANDed` together.
 func (s *UpdateStatement) WhereIn(column string, values ...interface{}) *UpdateStatement {
-	s.where = append(s.where, buildWhereIn(column, values...))
+	s.where = append(s.where, buildWhereIn(column, false, values...))
+	return s
+}
+
+// WhereNotIn adds a `WHERE NOT IN (values)` clause, multiple calls to WhereNotIn are `ANDed` together.
+func (s *UpdateStatement) WhereNotIn(column string, values ...interface{}) *UpdateStatement {
+	s.where = append(s.where, buildWhereIn(column, true, values...))
+	return s
+}
+
+// WhereAny adds a `WHERE value = ANY(column)` clause for membership in an array column,
+// multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereAny(value interface{}, column string) *UpdateStatement {
+	s.where = append(s.where, buildWhereAny(value, column))
+	return s
+}
+
+// WhereArrayContains adds a `WHERE column @> ARRAY[values...]` clause for array columns containing
+// all the given values, a single slice argument is expanded into the array values.
+// Building fails with ErrEmptyArray when there are no values.
+// Multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereArrayContains(column string, values ...interface{}) *UpdateStatement {
+	s.where = append(s.where, buildWhereArrayContains(column, values...))
 	return s
 }
 
-// Returning adds a `RETURNING columns` clause.
+// WhereJSONText adds a `WHERE column->>'path' op value` clause comparing the text value of a json field,
+// e.g. `WhereJSONText("data", "status", "=", "active")`. Multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereJSONText(column, path, op string, value interface{}) *UpdateStatement {
+	s.where = append(s.where, buildWhereJSONText(column, path, op, value))
+	return s
+}
+
+// WhereJSONContains adds a `WHERE column @> value::jsonb` clause, where value is encoded as JSON
+// unless it is a string or []byte. Multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereJSONContains(column string, value interface{}) *UpdateStatement {
+	s.where = append(s.where, buildWhereJSONContains(column, value))
+	return s
+}
+
+// WhereJSONHasKey adds a `WHERE jsonb_exists(column, 'key')` clause, the function form of the jsonb `?` key
+// existence operator, which is not mistaken for a placeholder by drivers using `?` placeholders.
+// Multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereJSONHasKey(column, key string) *UpdateStatement {
+	s.where = append(s.where, buildWhereJSONHasKey(column, key))
+	return s
+}
+
+// WhereDistinctFrom adds a NULL safe `WHERE column IS DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereDistinctFrom(column string, value interface{}) *UpdateStatement {
+	s.where = append(s.where, &Part{Query: column + " IS DISTINCT FROM ?", Values: []interface{}{value}})
+	return s
+}
+
+// WhereNotDistinctFrom adds a NULL safe `WHERE column IS NOT DISTINCT FROM value` clause,
+// multiple where clauses are `ANDed` together.
+func (s *UpdateStatement) WhereNotDistinctFrom(column string, value interface{}) *UpdateStatement {
+	s.where = append(s.where, &Part{Query: column + " IS NOT DISTINCT FROM ?", Values: []interface{}{value}})
+	return s
+}
+
+// Returning adds a `RETURNING columns` clause. Returning overwrites any previously returned columns or expressions.
 func (s *UpdateStatement) Returning(columns ...string) *UpdateStatement {
-	s.returning = columns
+	s.returning = buildReturning(columns)
+	return s
+}
+
+// ReturningExpr appends an expression to the `RETURNING` clause, e.g. `ReturningExpr("now() AS updated_at")`,
+// interpolating the given values. The expression is written as is and must not contain user provided input.
+func (s *UpdateStatement) ReturningExpr(expr string, values ...interface{}) *UpdateStatement {
+	s.returning = append(s.returning, &Part{Query: expr, Values: values})
+	return s
+}
+
+// OrderBy adds a `ORDER BY items` clause where each item is a verbatim `ORDER BY` element,
+// determining which rows are updated when used with Limit.
+// OrderBy overwrites any previously set ordering.
+func (s *UpdateStatement) OrderBy(items ...string) *UpdateStatement {
+	s.orderBy = items
+	return s
+}
+
+// Limit adds a `LIMIT n` clause, updating at most n rows, e.g. for backfilling in bounded batches.
+//
+// Dialects without support for `ORDER BY` and `LIMIT` in UPDATE statements, like PostgreSQL,
+// emulate them with a subquery selecting the keys of the rows to update,
+// `UPDATE table SET ... WHERE table.id IN (SELECT table.id FROM table WHERE ... ORDER BY ... LIMIT n)`.
+// The key column can be set with LimitKey and is qualified with the table name or alias.
+func (s *UpdateStatement) Limit(n int64) *UpdateStatement {
+	s.limitCount = n
+	return s
+}
+
+// LimitKey sets the column identifying the rows to update used when OrderBy or Limit are emulated
+// with a subquery, `id` by default, which should be the table primary key.
+// Unqualified keys are qualified with the table name or alias, so they are not ambiguous with other tables.
+func (s *UpdateStatement) LimitKey(column string) *UpdateStatement {
+	s.limitKey = column
 	return s
 }
 
 // Build builds the statement into the given buffer.
 func (s *UpdateStatement) Build(buf Buffer) (err error) {
-	for x := 0; x < len(s.comment); x++ {
-		if err = s.comment[x].Build(buf); err != nil {
+	buf = withDialect(buf, s.dialect)
+
+	if s.table == "" {
+		return fmt.Errorf("%w: update requires Table", ErrMissingTable)
+	}
+
+	if s.limitCount < 0 {
+		return fmt.Errorf("statement: update limit must not be negative, limit: %d", s.limitCount)
+	}
+
+	if !s.tailComments {
+		if err = buildComments(buf, s.comment); err != nil {
 			return err
 		}
-		_, _ = buf.WriteString("\n")
 	}
 
 	if s.with != nil {
@@ -122,13 +328,40 @@ func (s *UpdateStatement) Build(buf Buffer) (err error) {
 		}
 	}
 
-	if err = buildWhere(buf, s.where); err != nil {
+	if (len(s.orderBy) > 0 || s.limitCount > 0) && !dialectOf(buf).DMLLimit {
+		// emulated with a subquery selecting the keys of the rows to update
+		key := qualifiedKey(s.table, s.limitKey)
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(key)
+		_, _ = buf.WriteString(" IN (SELECT ")
+		_, _ = buf.WriteString(key)
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.table)
+
+		if err = buildWhere(buf, s.where); err != nil {
+			return err
+		}
+
+		if err = buildOrderLimit(buf, s.orderBy, s.limitCount); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	} else {
+		if err = buildWhere(buf, s.where); err != nil {
+			return err
+		}
+
+		if err = buildOrderLimit(buf, s.orderBy, s.limitCount); err != nil {
+			return err
+		}
+	}
+
+	if err = buildList(buf, " RETURNING ", s.returning); err != nil {
 		return err
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if s.tailComments {
+		return buildTrailingComments(buf, s.comment)
 	}
 
 	return nil
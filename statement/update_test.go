@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -28,6 +29,30 @@ var (
 			}).Where("id = ?", 123),
 			wantErr: false,
 		},
+		{
+			name:    "alias",
+			expect:  `UPDATE users AS u SET email = 'john.doe@email.com' WHERE u.id = 123`,
+			stmt:    Update().TableAs("users", "u").Set("email", "john.doe@email.com").Where("u.id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "default",
+			expect:  `UPDATE users SET role = DEFAULT WHERE id = 123`,
+			stmt:    Update().Table("users").Set("role", Default).Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "where_not_in",
+			expect:  `UPDATE users SET active = false WHERE role NOT IN ('admin','owner')`,
+			stmt:    Update().Table("users").Set("active", false).WhereNotIn("role", []string{"admin", "owner"}),
+			wantErr: false,
+		},
+		{
+			name:    "where_distinct_from",
+			expect:  `UPDATE users SET email = 'john.doe@email.com' WHERE id = 123 AND email IS DISTINCT FROM 'john.doe@email.com'`,
+			stmt:    Update().Table("users").Set("email", "john.doe@email.com").Where("id = ?", 123).WhereDistinctFrom("email", "john.doe@email.com"),
+			wantErr: false,
+		},
 		{
 			name:   "where_in",
 			expect: `UPDATE users SET email = 'john.doe@email.com', role = 'admin', user = 'john.doe' WHERE id IN (123,321)`,
@@ -61,6 +86,72 @@ var (
 			}).WhereIn("id", 123, 321).Returning("email"),
 			wantErr: false,
 		},
+		{
+			name:    "returning_expr",
+			expect:  `UPDATE users SET email = 'john.doe@email.com' WHERE id = 123 RETURNING id,now() AS updated_at,coalesce(role, 'user') AS role`,
+			stmt:    Update().Table("users").Set("email", "john.doe@email.com").Where("id = ?", 123).Returning("id").ReturningExpr("now() AS updated_at").ReturningExpr("coalesce(role, ?) AS role", "user"),
+			wantErr: false,
+		},
+		{
+			name:    "order_limit_mysql",
+			expect:  `UPDATE users SET migrated = 1 WHERE migrated = 0 ORDER BY id LIMIT 500`,
+			stmt:    MySQL().Update().Table("users").Set("migrated", true).Where("migrated = ?", false).OrderBy("id").Limit(500),
+			wantErr: false,
+		},
+		{
+			name:    "order_limit_subquery",
+			expect:  `UPDATE users SET migrated = true WHERE users.user_id IN (SELECT users.user_id FROM users WHERE migrated = false ORDER BY user_id LIMIT 500) RETURNING user_id`,
+			stmt:    Update().Table("users").Set("migrated", true).Where("migrated = ?", false).OrderBy("user_id").Limit(500).LimitKey("user_id").Returning("user_id"),
+			wantErr: false,
+		},
+		{
+			name:   "order_limit_subquery_alias",
+			expect: `UPDATE users AS u SET migrated = true WHERE u.id IN (SELECT u.id FROM users AS u WHERE EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.id) ORDER BY u.created_at LIMIT 500)`,
+			stmt: Update().TableAs("users", "u").Set("migrated", true).
+				Where("EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.id)").OrderBy("u.created_at").Limit(500),
+			wantErr: false,
+		},
+		{
+			name:    "negative_limit",
+			stmt:    Update().Table("users").Set("migrated", true).Limit(-1),
+			wantErr: true,
+		},
+		{
+			name:    "block_comment",
+			expect:  `/* controller='users' */ UPDATE users SET email = 'john.doe@email.com' WHERE id = 123`,
+			stmt:    Update().BlockComment("controller='users'").Table("users").Set("email", "john.doe@email.com").Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "record",
+			expect:  `UPDATE users SET email = null, id = 123, name = 'john.doe' WHERE id = 123`,
+			stmt:    Update().Table("users").Record(&nullableUser{ID: &nullableID, Name: &nullableName}).Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "record_embedded",
+			expect:  `UPDATE users SET created_by = 'admin', id = 123, name = 'john.doe', updated_by = 'system' WHERE id = 123`,
+			stmt:    Update().Table("users").Record(&auditedUser{ID: 123, auditInfo: auditInfo{CreatedBy: "admin", UpdatedBy: "system"}, Name: "john.doe"}).Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "record_non_zero_embedded",
+			expect:  `UPDATE users SET name = 'john.doe', updated_by = 'system' WHERE id = 123`,
+			stmt:    Update().Table("users").RecordNonZero(auditedUser{auditInfo: auditInfo{UpdatedBy: "system"}, Name: "john.doe"}).Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "record_non_zero",
+			expect:  `UPDATE users SET name = 'john.doe', updated_at = now() WHERE id = 123`,
+			stmt:    Update().Table("users").RecordNonZero(nullableUser{Name: &nullableName}).Set("updated_at", Ident("now()")).Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "trailing_block_comment",
+			expect:  `UPDATE users SET email = 'john.doe@email.com' WHERE id = 123 RETURNING id /* controller='users' */`,
+			stmt:    Update().BlockComment("controller='users'").TrailingComments().Table("users").Set("email", "john.doe@email.com").Where("id = ?", 123).Returning("id"),
+			wantErr: false,
+		},
 		{
 			name: "comment",
 			expect: `-- request id: 12435
@@ -90,3 +181,9 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateMissingTable(t *testing.T) {
+	if _, err := Update().Set("email", "john.doe@email.com").Where("id = ?", 123).String(); !errors.Is(err, ErrMissingTable) {
+		t.Fatalf("expected missing table error, got: %v", err)
+	}
+}
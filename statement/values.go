@@ -4,14 +4,124 @@ import (
 	"database/sql/driver"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/brunotm/norm/internal/buffer"
 )
 
-var rfc3339micro = "'2006-01-02T15:04:05.999999Z07:00'"
+// TimeLayout is the layout used to render time.Time values, RFC3339 with microseconds by default.
+// It can be changed globally, but only before statements are built.
+var TimeLayout = "2006-01-02T15:04:05.999999Z07:00"
+
+// ValuesStatement represents a standalone `VALUES` list, usable as a derived table.
+type ValuesStatement struct {
+	alias   string
+	columns []string
+	rows    []Statement
+}
+
+// Values creates a new `VALUES (row),(row)...` statement.
+// Each row is rendered as a parenthesized list of its values.
+func Values(rows ...[]interface{}) *ValuesStatement {
+	s := &ValuesStatement{}
+	for x := 0; x < len(rows); x++ {
+		s.Row(rows[x]...)
+	}
+	return s
+}
+
+// Clone returns a copy of the statement that can be modified without affecting the original.
+func (s *ValuesStatement) Clone() *ValuesStatement {
+	c := *s
+	c.columns = append([]string(nil), s.columns...)
+	c.rows = append([]Statement(nil), s.rows...)
+	return &c
+}
+
+// Row appends a row to the `VALUES` list.
+func (s *ValuesStatement) Row(values ...interface{}) *ValuesStatement {
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("(")
+	for x := 0; x < len(values); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString("?")
+	}
+	_, _ = buf.WriteString(")")
+
+	s.rows = append(s.rows, &Part{Query: buf.String(), Values: values})
+	return s
+}
+
+// As sets the alias and optional column names for the `VALUES` list, rendering it as
+// a derived table `(VALUES ...) AS alias(columns)` that can be used in the `FROM` clause.
+func (s *ValuesStatement) As(alias string, columns ...string) *ValuesStatement {
+	s.alias = alias
+	s.columns = columns
+	return s
+}
+
+// Build builds the statement into the given buffer.
+func (s *ValuesStatement) Build(buf Buffer) (err error) {
+	if s.alias != "" {
+		_, _ = buf.WriteString("(")
+	}
+
+	_, _ = buf.WriteString("VALUES ")
+	for x := 0; x < len(s.rows); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+
+		if err = s.rows[x].Build(buf); err != nil {
+			return err
+		}
+	}
+
+	if s.alias != "" {
+		_, _ = buf.WriteString(") AS ")
+		_, _ = buf.WriteString(s.alias)
+
+		if len(s.columns) > 0 {
+			_, _ = buf.WriteString("(")
+			_, _ = buf.WriteString(strings.Join(s.columns, ","))
+			_, _ = buf.WriteString(")")
+		}
+	}
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *ValuesStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
 
 func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
+	// values are collected as arguments when building parameterized statements
+	if _, ok := arg.(Ident); !ok && !keyword && writeParam(buf, paramValue(arg)) {
+		return nil
+	}
+
+	// nil pointers are rendered as null
+	if arg = deref(arg); arg == nil {
+		_, _ = buf.WriteString("null")
+		return nil
+	}
+
 	if v, ok := arg.(driver.Valuer); ok {
 		if arg, err = v.Value(); err != nil {
 			return err
@@ -36,9 +146,18 @@ func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
 	case float64:
 		_, _ = buf.WriteString(strconv.FormatFloat(arg, 'f', -1, 64))
 	case bool:
-		_, _ = buf.WriteString(strconv.FormatBool(arg))
+		switch {
+		case !dialectOf(buf).BoolAsInt:
+			_, _ = buf.WriteString(strconv.FormatBool(arg))
+		case arg:
+			_, _ = buf.WriteString("1")
+		default:
+			_, _ = buf.WriteString("0")
+		}
 	case []byte:
 		quoteBytes(arg, buf)
+	case Ident:
+		_, _ = buf.WriteString(string(arg))
 	case string:
 		if keyword {
 			_, _ = buf.WriteString(arg)
@@ -46,7 +165,10 @@ func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
 			quoteString(arg, buf)
 		}
 	case time.Time:
-		_, _ = buf.WriteString(arg.Format(rfc3339micro))
+		quoteString(arg.Format(TimeLayout), buf)
+	case time.Duration:
+		// rendered as an interval literal, e.g. '5400 seconds'
+		quoteString(strconv.FormatFloat(arg.Seconds(), 'f', -1, 64)+" seconds", buf)
 	case fmt.Stringer:
 		quoteString(arg.String(), buf)
 	default:
@@ -58,6 +180,10 @@ func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
 
 // TODO: consider manually inlining this
 func quoteString(str string, buf Buffer) {
+	if dialectOf(buf).BackslashEscapes {
+		str = strings.ReplaceAll(str, `\`, `\\`)
+	}
+
 	_, _ = buf.WriteString(`'`)
 	_, _ = buf.WriteString(strings.ReplaceAll(str, "'", "''"))
 	_, _ = buf.WriteString(`'`)
@@ -65,7 +191,53 @@ func quoteString(str string, buf Buffer) {
 
 // TODO: consider manually inlining this
 func quoteBytes(b []byte, buf Buffer) {
+	if dialectOf(buf).BackslashEscapes {
+		_, _ = buf.WriteString(`X'`)
+		_, _ = buf.WriteString(hex.EncodeToString(b))
+		_, _ = buf.WriteString(`'`)
+		return
+	}
+
 	_, _ = buf.WriteString(`'\x`)
 	_, _ = buf.WriteString(hex.EncodeToString(b))
 	_, _ = buf.WriteString(`'`)
 }
+
+// paramValue returns the argument passed to the database driver for the given value in parameterized
+// statements, converting the values the driver would not handle as they are interpolated.
+func paramValue(arg interface{}) interface{} {
+	arg = deref(arg)
+	switch arg := arg.(type) {
+	case driver.Valuer, time.Time:
+		return arg
+	case time.Duration:
+		return strconv.FormatFloat(arg.Seconds(), 'f', -1, 64) + " seconds"
+	case fmt.Stringer:
+		return arg.String()
+	}
+
+	return arg
+}
+
+// deref dereferences non nil pointers which are not a driver.Valuer, so that pointers to supported types
+// are handled as their values, e.g. *time.Time is rendered as time.Time and not through its String method.
+// Nil pointers are returned as nil.
+func deref(arg interface{}) interface{} {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+
+		if _, ok := v.Interface().(driver.Valuer); ok {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
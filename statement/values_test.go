@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	valuesCases = []struct {
+		name    string
+		expect  string
+		stmt    Statement
+		wantErr bool
+	}{
+		{
+			name:    "simple",
+			expect:  `VALUES (1,'a'),(2,'b')`,
+			stmt:    Values([]interface{}{1, "a"}, []interface{}{2, "b"}),
+			wantErr: false,
+		},
+		{
+			name:    "row",
+			expect:  `VALUES (1,'a'),(2,null)`,
+			stmt:    Values().Row(1, "a").Row(2, nil),
+			wantErr: false,
+		},
+		{
+			name:    "derived_table",
+			expect:  `SELECT * FROM (VALUES (1,'a'),(2,'b')) AS t(id,name)`,
+			stmt:    Select().Columns("*").From(Values([]interface{}{1, "a"}, []interface{}{2, "b"}).As("t", "id", "name")),
+			wantErr: false,
+		},
+		{
+			name:   "derived_table_join",
+			expect: `SELECT u.id,t.name FROM (VALUES (1,'a'),(2,'b')) AS t(id,name) INNER JOIN users AS u ON u.id = t.id WHERE t.name <> 'c'`,
+			stmt: Select().Columns("u.id", "t.name").
+				From(Values([]interface{}{1, "a"}, []interface{}{2, "b"}).As("t", "id", "name")).
+				JoinInner("users AS u", "u.id = t.id").Where("t.name <> ?", "c"),
+			wantErr: false,
+		},
+	}
+)
+
+func TestValues(t *testing.T) {
+	for _, tt := range valuesCases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.stmt.String()
+			if !tt.wantErr && err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+		})
+	}
+}
+
+func TestWriteValueTime(t *testing.T) {
+	date := time.Date(2021, 10, 1, 12, 30, 15, 123456000, time.UTC)
+
+	s, err := Select().Columns("id").From("users").
+		Where("created_at > ? AND age > ?::interval", date, 90*time.Minute+500*time.Millisecond).String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT id FROM users WHERE created_at > '2021-10-01T12:30:15.123456Z' AND age > '5400.5 seconds'::interval`
+	if expect != s {
+		t.Fatalf("expected: %s, got: %s", expect, s)
+	}
+
+	layout := TimeLayout
+	TimeLayout = "2006-01-02 15:04:05"
+	defer func() { TimeLayout = layout }()
+
+	s, err = Select().Columns("id").From("users").Where("created_at > ?", date).String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect = `SELECT id FROM users WHERE created_at > '2021-10-01 12:30:15'`
+	if expect != s {
+		t.Fatalf("expected: %s, got: %s", expect, s)
+	}
+}